@@ -0,0 +1,63 @@
+package check
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// argumentFile is an argument of a check, like "source", that the
+// check can read from a file, with the "<name>_file" argument, rather
+// than inline in the config.
+type argumentFile struct {
+	name  string
+	file  string
+	value *string
+}
+
+// resolveArgumentFile returns the path of an argument file, which is
+// relative to the directory of the config file unless it's absolute.
+func resolveArgumentFile(dir, fn string) string {
+	if fn == "" || filepath.IsAbs(fn) {
+		return fn
+	}
+
+	return filepath.Join(dir, fn)
+}
+
+// readArgumentFiles sets the arguments from the files that specify
+// them, and returns the paths of the files. Arguments can't be both
+// inline and in a file.
+func readArgumentFiles(dir string, files ...argumentFile) ([]string, error) {
+	catcher := grip.NewCatcher()
+
+	var paths []string
+	for _, arg := range files {
+		if arg.file == "" {
+			continue
+		}
+
+		if *arg.value != "" {
+			catcher.Add(errors.Errorf("cannot specify both '%s' and '%s_file'", arg.name, arg.name))
+			continue
+		}
+
+		path := resolveArgumentFile(dir, arg.file)
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			catcher.Add(errors.Wrapf(err, "problem reading %s file", arg.name))
+			continue
+		}
+
+		*arg.value = string(data)
+		paths = append(paths, path)
+	}
+
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+
+	return paths, nil
+}
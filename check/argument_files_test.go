@@ -0,0 +1,57 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadArgumentFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-argument-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "programs"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "programs", "hello.py"), []byte("print('hello')\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "hello.out"), []byte("hello\n"), 0644))
+
+	assert.Equal("", resolveArgumentFile(dir, ""))
+	assert.Equal(filepath.Join(dir, "hello.out"), resolveArgumentFile(dir, "hello.out"))
+	assert.Equal(filepath.Join(dir, "hello.out"), resolveArgumentFile("/elsewhere", filepath.Join(dir, "hello.out")))
+
+	check := &programOutputCheck{
+		Base:       NewBase("run-program-system-python", 0),
+		SourceFile: "programs/hello.py",
+		OutputFile: filepath.Join(dir, "hello.out"),
+	}
+	paths, err := check.ReadArgumentFiles(dir)
+	assert.NoError(err)
+	assert.Equal([]string{filepath.Join(dir, "programs", "hello.py"), filepath.Join(dir, "hello.out")}, paths)
+	assert.Equal("print('hello')\n", check.Source)
+	assert.Equal("hello\n", check.ExpectedOutput)
+	assert.Equal(filepath.Join(dir, "hello.out"), check.ExpectedOutputFile())
+
+	// files without arguments files don't read anything
+	script := &scriptCheck{Base: NewBase("run-script", 0), Source: "echo hi"}
+	paths, err = script.ReadArgumentFiles(dir)
+	assert.NoError(err)
+	assert.Len(paths, 0)
+	assert.Equal("", script.ExpectedOutputFile())
+
+	// arguments can't be both inline and in files
+	script = &scriptCheck{Base: NewBase("run-script", 0), Source: "echo hi", SourceFile: "programs/hello.py"}
+	_, err = script.ReadArgumentFiles(dir)
+	assert.Error(err)
+
+	// files must exist
+	compile := &compileCheck{Base: NewBase("compile-gcc-auto", 0), SourceFile: "programs/hello.c"}
+	_, err = compile.ReadArgumentFiles(dir)
+	assert.Error(err)
+	assert.Equal("", compile.Source)
+}
@@ -0,0 +1,188 @@
+package check
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "system-clock"
+	registry.AddJobType(name, func() amboy.Job {
+		return &systemClock{
+			Base: NewBase(name, 0), // (name, version)
+		}
+	})
+}
+
+// systemClock asserts that the system's wall clock is sane. It
+// always measures the drift between the wall and monotonic clocks
+// over a short sample, which catches clocks that are being stepped
+// or slewed aggressively. It can also assert that the clock is later
+// than the modification time of a reference file, and that it is
+// within an offset of an (S)NTP server.
+type systemClock struct {
+	ReferenceFile string `bson:"reference_file" json:"reference_file" yaml:"reference_file"`
+	Server        string `bson:"server" json:"server" yaml:"server"`
+	MaxOffset     int    `bson:"max_offset_ms" json:"max_offset_ms" yaml:"max_offset_ms"`
+	Sample        int    `bson:"sample_ms" json:"sample_ms" yaml:"sample_ms"`
+	MaxDrift      int    `bson:"max_drift_ms" json:"max_drift_ms" yaml:"max_drift_ms"`
+	Timeout       int    `bson:"timeout_secs" json:"timeout_secs" yaml:"timeout_secs"`
+	*Base         `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+func (c *systemClock) validate() error {
+	if c.MaxOffset < 0 || c.Sample < 0 || c.MaxDrift < 0 || c.Timeout < 0 {
+		return errors.New("clock check thresholds cannot be negative")
+	}
+
+	if c.MaxOffset == 0 {
+		c.MaxOffset = 1000
+	}
+
+	if c.Sample == 0 {
+		c.Sample = 100
+	}
+
+	if c.MaxDrift == 0 {
+		c.MaxDrift = 50
+	}
+
+	if c.Timeout == 0 {
+		c.Timeout = 5
+	}
+
+	if c.Server != "" {
+		if _, _, err := net.SplitHostPort(c.Server); err != nil {
+			c.Server = net.JoinHostPort(c.Server, "123")
+		}
+	}
+
+	return nil
+}
+
+func (c *systemClock) Run(_ context.Context) {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	catcher := grip.NewCatcher()
+	var msgs []string
+
+	start := time.Now()
+	time.Sleep(time.Duration(c.Sample) * time.Millisecond)
+	end := time.Now()
+
+	// Round(0) strips the monotonic reading, so the second
+	// difference only uses the wall clock.
+	drift := end.Round(0).Sub(start.Round(0)) - end.Sub(start)
+	msgs = append(msgs, fmt.Sprintf("wall/monotonic drift=%s over %dms", drift, c.Sample))
+	if absDuration(drift) > time.Duration(c.MaxDrift)*time.Millisecond {
+		catcher.Add(errors.Errorf("wall clock drifted %s from the monotonic clock in %dms",
+			drift, c.Sample))
+	}
+
+	if c.ReferenceFile != "" {
+		stat, err := os.Stat(c.ReferenceFile)
+		if err != nil {
+			catcher.Add(errors.Wrapf(err, "problem reading reference file '%s'", c.ReferenceFile))
+		} else if now := time.Now(); now.Before(stat.ModTime()) {
+			catcher.Add(errors.Errorf("system time %s is earlier than the modification time of '%s' (%s)",
+				now, c.ReferenceFile, stat.ModTime()))
+		}
+	}
+
+	if c.Server != "" {
+		offset, err := queryNTPOffset(c.Server, time.Duration(c.Timeout)*time.Second)
+		if err != nil {
+			catcher.Add(err)
+		} else {
+			msgs = append(msgs, fmt.Sprintf("offset from '%s'=%s", c.Server, offset))
+			if absDuration(offset) > time.Duration(c.MaxOffset)*time.Millisecond {
+				catcher.Add(errors.Errorf("clock is offset %s from '%s', more than %dms",
+					offset, c.Server, c.MaxOffset))
+			}
+		}
+	}
+
+	c.setMessage(strings.Join(msgs, ", "))
+
+	if catcher.HasErrors() {
+		c.setState(false)
+		c.AddError(catcher.Resolve())
+		return
+	}
+
+	c.setState(true)
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// ntpEpochOffset is the number of seconds between the NTP epoch
+// (1900) and the unix epoch (1970).
+const ntpEpochOffset = 2208988800
+
+func ntpTime(data []byte) time.Time {
+	secs := int64(binary.BigEndian.Uint32(data[0:4])) - ntpEpochOffset
+	frac := int64(binary.BigEndian.Uint32(data[4:8]))
+
+	return time.Unix(secs, (frac*1e9)>>32)
+}
+
+// queryNTPOffset sends a single SNTP (RFC 4330) client request and
+// returns the estimated offset of the local clock from the server.
+// Positive offsets mean the local clock is behind.
+func queryNTPOffset(addr string, timeout time.Duration) (time.Duration, error) {
+	conn, err := net.DialTimeout("udp", addr, timeout)
+	if err != nil {
+		return 0, errors.Wrapf(err, "problem connecting to time server '%s'", addr)
+	}
+	defer conn.Close()
+
+	if err = conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	req := make([]byte, 48)
+	req[0] = 0x23 // leap indicator 0, version 4, mode 3 (client)
+
+	sent := time.Now()
+	if _, err = conn.Write(req); err != nil {
+		return 0, errors.Wrapf(err, "problem sending request to time server '%s'", addr)
+	}
+
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	received := time.Now()
+	if err != nil {
+		return 0, errors.Wrapf(err, "problem reading response from time server '%s'", addr)
+	}
+
+	if n < 48 || resp[0]&0x7 != 4 {
+		return 0, errors.Errorf("invalid response from time server '%s'", addr)
+	}
+
+	serverReceived := ntpTime(resp[32:40])
+	serverSent := ntpTime(resp[40:48])
+
+	return (serverReceived.Sub(sent) + serverSent.Sub(received)) / 2, nil
+}
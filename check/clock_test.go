@@ -0,0 +1,109 @@
+package check
+
+import (
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startFakeNTPServer answers SNTP requests with the local time plus
+// the given offset, and returns the server's address.
+func startFakeNTPServer(t *testing.T, offset time.Duration) (string, func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		buf := make([]byte, 48)
+		for {
+			_, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			now := time.Now().Add(offset)
+			secs := uint32(now.Unix() + ntpEpochOffset)
+			frac := uint32((int64(now.Nanosecond()) << 32) / 1e9)
+
+			resp := make([]byte, 48)
+			resp[0] = 0x24 // version 4, mode 4 (server)
+			for _, start := range []int{32, 40} {
+				binary.BigEndian.PutUint32(resp[start:], secs)
+				binary.BigEndian.PutUint32(resp[start+4:], frac)
+			}
+			_, _ = conn.WriteTo(resp, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String(), func() { conn.Close() }
+}
+
+func TestSystemClockDefaults(t *testing.T) {
+	assert := assert.New(t)
+
+	check := &systemClock{Base: NewBase("system-clock", 0), Server: "time.example.net"}
+	assert.NoError(check.validate())
+	assert.Equal(1000, check.MaxOffset)
+	assert.Equal(100, check.Sample)
+	assert.Equal("time.example.net:123", check.Server)
+
+	check.MaxDrift = -1
+	assert.Error(check.validate())
+}
+
+func TestSystemClockCheck(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	check := &systemClock{Base: NewBase("system-clock", 0), Sample: 10}
+	check.Run(ctx)
+	assert.True(check.Output().Passed, check.Output().Error)
+	assert.NoError(check.Error())
+
+	// a reference file from the future means the clock is wrong.
+	ref, err := ioutil.TempFile("", "greenbay-clock-")
+	require.NoError(t, err)
+	defer os.Remove(ref.Name())
+	require.NoError(t, ref.Close())
+
+	check = &systemClock{Base: NewBase("system-clock", 0), Sample: 10, ReferenceFile: ref.Name()}
+	check.Run(ctx)
+	assert.True(check.Output().Passed, check.Output().Error)
+
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(ref.Name(), future, future))
+	check = &systemClock{Base: NewBase("system-clock", 0), Sample: 10, ReferenceFile: ref.Name()}
+	check.Run(ctx)
+	assert.False(check.Output().Passed)
+	assert.Error(check.Error())
+}
+
+func TestSystemClockServerOffset(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	addr, closer := startFakeNTPServer(t, 0)
+	defer closer()
+
+	offset, err := queryNTPOffset(addr, time.Second)
+	assert.NoError(err)
+	assert.True(absDuration(offset) < 100*time.Millisecond, offset.String())
+
+	check := &systemClock{Base: NewBase("system-clock", 0), Sample: 10, Server: addr}
+	check.Run(ctx)
+	assert.True(check.Output().Passed, check.Output().Error)
+
+	skewed, skewCloser := startFakeNTPServer(t, time.Minute)
+	defer skewCloser()
+
+	check = &systemClock{Base: NewBase("system-clock", 0), Sample: 10, Server: skewed}
+	check.Run(ctx)
+	assert.False(check.Output().Passed)
+	assert.Error(check.Error())
+}
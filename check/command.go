@@ -33,19 +33,44 @@ func init() {
 	}
 }
 
+// shellOperation runs a command with "sh -c", and passes when the
+// command succeeds, or, for "shell-operation-error", when it fails. If
+// the check has an expected output, the output of the command must
+// also match it, using the match mode and options in output_match.go.
 type shellOperation struct {
 	Command          string            `bson:"command" json:"command" yaml:"command"`
 	WorkingDirectory string            `bson:"working_directory" json:"working_directory" yaml:"working_directory"`
 	Environment      map[string]string `bson:"environment" json:"environment" yaml:"environment"`
+	ExpectedOutput   string            `bson:"output" json:"output" yaml:"output"`
+	Match            string            `bson:"match" json:"match" yaml:"match"`
+	IgnoreWhitespace bool              `bson:"ignore_whitespace" json:"ignore_whitespace" yaml:"ignore_whitespace"`
+	IgnoreCase       bool              `bson:"ignore_case" json:"ignore_case" yaml:"ignore_case"`
 	*Base            `bson:"metadata" json:"metadata,omitempty" yaml:"metadata,omitempty"`
 
 	shouldFail bool
+	goldenOutput
+}
+
+func (c *shellOperation) matcher() outputMatcher {
+	return outputMatcher{
+		mode:             c.Match,
+		ignoreWhitespace: c.IgnoreWhitespace,
+		ignoreCase:       c.IgnoreCase,
+	}
 }
 
 func (c *shellOperation) Run(_ context.Context) {
 	c.startTask()
 	defer c.MarkComplete()
 
+	if c.ExpectedOutput != "" {
+		if err := c.matcher().validate(c.ExpectedOutput); err != nil {
+			c.setState(false)
+			c.AddError(errors.Wrapf(err, "problem with expected output for '%s'", c.ID()))
+			return
+		}
+	}
+
 	logMsg := []string{fmt.Sprintf("command='%s'", c.Command)}
 
 	// I don't like "sh -c" as a thing, but it parallels the way
@@ -90,5 +115,15 @@ func (c *shellOperation) Run(_ context.Context) {
 
 	if !c.getState() {
 		c.setMessage(string(out))
+		return
+	}
+
+	if c.ExpectedOutput != "" {
+		if msg, err := c.matcher().match(c.ExpectedOutput, string(out)); err != nil {
+			c.setState(false)
+			c.AddError(err)
+			c.setMessage(msg)
+			c.setActualOutput(c.matcher(), string(out))
+		}
 	}
 }
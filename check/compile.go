@@ -2,11 +2,15 @@ package check
 
 import (
 	"context"
-	"io/ioutil"
+	"fmt"
 	"os"
 	"os/exec"
-	"runtime"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"syscall"
 
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/registry"
@@ -16,46 +20,152 @@ import (
 
 type compiler interface {
 	Validate() error
-	Compile(string, ...string) error
-	CompileAndRun(string, ...string) (string, error)
+	Compile(string, compileOptions) error
+	CompileAndRun(string, compileOptions) (string, error)
+}
+
+// compileOptions holds the flags for a compiler: cflags go before the
+// source file on the command line, and ldflags (e.g. libraries) go
+// after it, so that the linker can resolve the source's symbols. The
+// language selects the source file's extension and, for compilers
+// like gcc, the driver.
+//
+// The files, keyed by relative path, are written next to the test
+// body, and the compiler builds those that are source files with it.
+// The args, stdin, env, and directory are used to run the compiled
+// program or script. The test is written to and built in the
+// workspace, which is owned by the check; without a workspace, the
+// compiler uses its own temporary directory.
+type compileOptions struct {
+	workspace string
+	language  string
+	cflags    []string
+	ldflags   []string
+	files     map[string]string
+	libraries []string
+	args      []string
+	stdin     string
+	env       map[string]string
+	directory string
+}
+
+// compileLanguages maps the languages of compiled checks to the
+// extensions of their source files.
+var compileLanguages = map[string]string{
+	"c":   "c",
+	"c++": "cpp",
+}
+
+func (o compileOptions) extension() string {
+	if ext, ok := compileLanguages[o.language]; ok {
+		return ext
+	}
+
+	return "c"
 }
 
 type compilerFactory func() compiler
 
-func writeTestBody(testBody, ext string) (string, string, error) {
-	testFile, err := ioutil.TempFile(os.TempDir(), "testBody_")
-	if err != nil {
-		return "", "", err
+// validateTestFiles makes sure that the names of additional files for
+// a test are relative paths inside of the test's directory.
+func validateTestFiles(files map[string]string) error {
+	catcher := grip.NewCatcher()
+	for name := range files {
+		clean := path.Clean(filepath.ToSlash(name))
+		if name == "" || path.IsAbs(clean) || filepath.IsAbs(name) || clean == ".." || strings.HasPrefix(clean, "../") {
+			catcher.Add(errors.Errorf("file name '%s' must be a relative path in the test directory", name))
+		}
 	}
 
-	baseName := testFile.Name()
-	sourceName := strings.Join([]string{baseName, ext}, ".")
+	return catcher.Resolve()
+}
 
-	if runtime.GOOS == "windows" {
-		testBody = strings.Replace(testBody, "\n", "\r\n", -1)
+// compileError reports that the compiler ran, but failed to compile
+// the test, as opposed to failing to start, and holds the compiler's
+// output.
+type compileError struct {
+	output string
+	err    error
+}
+
+func (e *compileError) Error() string {
+	return fmt.Sprintf("problem compiling test (%s): %s", e.err.Error(), e.output)
+}
+
+// newCompileError returns a compileError if the compiler exited with
+// an error, and otherwise reports that the compiler couldn't run.
+func newCompileError(err error, output []byte) error {
+	if _, ok := err.(*exec.ExitError); ok {
+		return &compileError{output: string(output), err: err}
 	}
 
-	_, err = testFile.Write([]byte(testBody))
-	if err != nil {
-		return "", "", errors.Wrap(err, "problem writing test to file")
+	return errors.Wrap(err, "problem running compiler")
+}
+
+// programExitError reports that a test program ran, but exited with a
+// non-zero exit code, as opposed to failing to compile or start.
+type programExitError struct {
+	code int
+	err  error
+}
+
+func (e *programExitError) Error() string {
+	return fmt.Sprintf("test program exited with code %d: %s", e.code, e.err.Error())
+}
+
+// runTestProgram runs a compiled test program with the arguments and
+// standard input from the options.
+func runTestProgram(program string, opts compileOptions) (string, error) {
+	return runTestCommand(exec.Command(program, opts.args...), opts)
+}
+
+// runTestCommand runs a test program or script with the standard
+// input, environment, and working directory from the options.
+func runTestCommand(cmd *exec.Cmd, opts compileOptions) (string, error) {
+	if opts.stdin != "" {
+		cmd.Stdin = strings.NewReader(opts.stdin)
 	}
-	defer grip.CatchWarning(testFile.Close())
 
-	if err = os.Rename(baseName, sourceName); err != nil {
-		return "", "", errors.Wrap(err, "problem renaming file")
+	if opts.directory != "" {
+		cmd.Dir = opts.directory
+	}
+
+	if len(opts.env) > 0 {
+		keys := make([]string, 0, len(opts.env))
+		for key := range opts.env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
 
+		cmd.Env = os.Environ()
+		for _, key := range keys {
+			cmd.Env = append(cmd.Env, key+"="+opts.env[key])
+		}
+	}
+
+	grip.Infof("running test command: %s", strings.Join(cmd.Args, " "))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Exited() {
+				return string(out), &programExitError{code: status.ExitStatus(), err: err}
+			}
+		}
+
+		return string(out), errors.Wrap(err, "problem running test program")
 	}
 
-	return baseName, sourceName, nil
+	return strings.Trim(string(out), "\r\t\n "), nil
 }
 
 func registerCompileChecks() {
-	compileCheckFactoryFactory := func(name string, c compiler, shouldRun bool) func() amboy.Job {
+	compileCheckFactoryFactory := func(name string, c compiler, shouldRun, shouldFail bool) func() amboy.Job {
 		return func() amboy.Job {
 			return &compileCheck{
-				Base:          NewBase(name, 0),
-				shouldRunCode: shouldRun,
-				compiler:      c,
+				Base:             NewBase(name, 0),
+				shouldRunCode:    shouldRun,
+				shouldNotCompile: shouldFail,
+				compiler:         c,
 			}
 		}
 	}
@@ -71,8 +181,14 @@ func registerCompileChecks() {
 				}
 
 				registry.AddJobType(jobName,
-					compileCheckFactoryFactory(jobName, factory(), shouldRun))
+					compileCheckFactoryFactory(jobName, factory(), shouldRun, false))
 			}
+
+			// Take a check like "compile-gcc-auto", and add an
+			// additional check called "compile-gcc-auto-fails"
+			// that passes when the test doesn't compile.
+			jobName = name + "-fails"
+			registry.AddJobType(jobName, compileCheckFactoryFactory(jobName, factory(), false, true))
 		}
 	}
 
@@ -80,13 +196,102 @@ func registerCompileChecks() {
 	registrar(goCompilerIterfaceFactoryTable())
 }
 
+// compileCheck compiles, and optionally runs, a test program in the
+// given language ("c", the default, or "c++"). The cflags_command and
+// ldflags_command (e.g. "pkg-config --cflags openssl" and "pkg-config
+// --libs openssl") run before compiling, and their output is split
+// into words like a shell would, and added before the cflags and
+// ldflags respectively.
+//
+// Tests can have additional files (sources and headers), keyed by
+// their path relative to the source, and link against libraries by
+// name. The "compile-and-run-*" checks pass args and stdin to the
+// program, and pass when it exits with the expected exit code.
+//
+// The "compile-*-fails" checks pass when the compiler runs but fails to
+// compile the test, and its output matches all of the diagnostics
+// regular expressions, so that a missing compiler, or a failure for
+// another reason, doesn't pass.
+//
+// The source can be in a file (source_file), relative to the config
+// file, instead.
 type compileCheck struct {
-	Source        string   `bson:"source" json:"source" yaml:"source"`
-	Cflags        []string `bson:"cflags" json:"cflags" yaml:"cflags"`
-	CflagsCommand string   `bson:"cflags_command" json:"cflags_command" yaml:"cflags_command"`
-	*Base         `bson:"metadata" json:"metadata" yaml:"metadata"`
-	shouldRunCode bool
-	compiler      compiler
+	Source         string            `bson:"source" json:"source" yaml:"source"`
+	SourceFile     string            `bson:"source_file" json:"source_file" yaml:"source_file"`
+	Files          map[string]string `bson:"files" json:"files" yaml:"files"`
+	Language       string            `bson:"language" json:"language" yaml:"language"`
+	Cflags         []string          `bson:"cflags" json:"cflags" yaml:"cflags"`
+	CflagsCommand  string            `bson:"cflags_command" json:"cflags_command" yaml:"cflags_command"`
+	Ldflags        []string          `bson:"ldflags" json:"ldflags" yaml:"ldflags"`
+	LdflagsCommand string            `bson:"ldflags_command" json:"ldflags_command" yaml:"ldflags_command"`
+	Libraries      []string          `bson:"libraries" json:"libraries" yaml:"libraries"`
+	Args           []string          `bson:"args" json:"args" yaml:"args"`
+	Stdin          string            `bson:"stdin" json:"stdin" yaml:"stdin"`
+	ExitCode       int               `bson:"exit_code" json:"exit_code" yaml:"exit_code"`
+	Diagnostics    []string          `bson:"diagnostics" json:"diagnostics" yaml:"diagnostics"`
+	*Base          `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	shouldRunCode    bool
+	shouldNotCompile bool
+	compiler         compiler
+}
+
+// ReadArgumentFiles reads the source file.
+func (c *compileCheck) ReadArgumentFiles(dir string) ([]string, error) {
+	paths, err := readArgumentFiles(dir,
+		argumentFile{name: "source", file: c.SourceFile, value: &c.Source})
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem reading files for '%s'", c.ID())
+	}
+
+	return paths, nil
+}
+
+func (c *compileCheck) options() (compileOptions, error) {
+	opts := compileOptions{
+		language:  c.Language,
+		files:     c.Files,
+		libraries: c.Libraries,
+		args:      c.Args,
+		stdin:     c.Stdin,
+	}
+
+	if opts.language == "" {
+		opts.language = "c"
+	}
+
+	if _, ok := compileLanguages[opts.language]; !ok {
+		return opts, errors.Errorf("language '%s' is not supported for '%s' (%s)",
+			c.Language, c.ID(), c.Name())
+	}
+
+	if c.Source == "" && len(c.Files) == 0 {
+		return opts, errors.Errorf("no source specified for '%s' (%s)", c.ID(), c.Name())
+	}
+
+	if err := validateTestFiles(c.Files); err != nil {
+		return opts, errors.Wrapf(err, "invalid files for '%s' (%s)", c.ID(), c.Name())
+	}
+
+	if c.CflagsCommand != "" {
+		flags, err := runFlagsCommand(c.CflagsCommand)
+		if err != nil {
+			return opts, errors.Wrap(err, "problem running cflags command")
+		}
+		opts.cflags = append(opts.cflags, flags...)
+	}
+	opts.cflags = append(opts.cflags, c.Cflags...)
+
+	if c.LdflagsCommand != "" {
+		flags, err := runFlagsCommand(c.LdflagsCommand)
+		if err != nil {
+			return opts, errors.Wrap(err, "problem running ldflags command")
+		}
+		opts.ldflags = append(opts.ldflags, flags...)
+	}
+	opts.ldflags = append(opts.ldflags, c.Ldflags...)
+
+	return opts, nil
 }
 
 func (c *compileCheck) Run(_ context.Context) {
@@ -99,32 +304,44 @@ func (c *compileCheck) Run(_ context.Context) {
 		return
 	}
 
-	cflags := []string{}
-	if c.CflagsCommand != "" {
-		output, err := exec.Command("net-snmp-config", "--agent-libs").CombinedOutput()
-		if err != nil {
-			c.setState(false)
-			c.AddError(err)
-			return
-		}
-
-		cflags = append(cflags, strings.Split(strings.TrimSpace(string(output)), " ")...)
+	opts, err := c.options()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
 	}
 
-	if len(c.Cflags) >= 1 {
-		cflags = append(cflags, c.Cflags...)
+	opts.workspace, err = newTestWorkspace()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
 	}
+	defer c.cleanupTestWorkspace(opts.workspace)
+
+	if c.shouldNotCompile {
+		c.checkCompileFails(opts)
+	} else if c.shouldRunCode {
+		output, err := c.compiler.CompileAndRun(c.Source, opts)
+		code := 0
+		if exitErr, ok := errors.Cause(err).(*programExitError); ok {
+			code = exitErr.code
+			err = nil
+		}
 
-	if c.shouldRunCode {
-		if output, err := c.compiler.CompileAndRun(c.Source, cflags...); err != nil {
+		if err != nil {
 			c.setState(false)
 			c.AddError(err)
 			c.setMessage(output)
+		} else if code != c.ExitCode {
+			c.setState(false)
+			c.AddError(errors.Errorf("test program exited with code %d, expected %d", code, c.ExitCode))
+			c.setMessage(output)
 		} else {
 			c.setState(true)
 		}
 	} else {
-		if err := c.compiler.Compile(c.Source, cflags...); err != nil {
+		if err := c.compiler.Compile(c.Source, opts); err != nil {
 			c.setState(false)
 			c.AddError(err)
 		} else {
@@ -132,3 +349,53 @@ func (c *compileCheck) Run(_ context.Context) {
 		}
 	}
 }
+
+func (c *compileCheck) checkCompileFails(opts compileOptions) {
+	patterns := make([]*regexp.Regexp, 0, len(c.Diagnostics))
+	catcher := grip.NewCatcher()
+	for _, expr := range c.Diagnostics {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			catcher.Add(errors.Wrapf(err, "diagnostic '%s' is not a valid regular expression", expr))
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	if catcher.HasErrors() {
+		c.setState(false)
+		c.AddError(catcher.Resolve())
+		return
+	}
+
+	err := c.compiler.Compile(c.Source, opts)
+	if err == nil {
+		c.setState(false)
+		c.AddError(errors.Errorf("test compiled, but should fail for '%s' (%s)", c.ID(), c.Name()))
+		return
+	}
+
+	compileErr, ok := errors.Cause(err).(*compileError)
+	if !ok {
+		c.setState(false)
+		c.AddError(errors.Wrap(err, "test did not fail to compile because of the compiler's diagnostics"))
+		return
+	}
+
+	var missing []string
+	for _, pattern := range patterns {
+		if !pattern.MatchString(compileErr.output) {
+			missing = append(missing, pattern.String())
+		}
+	}
+
+	c.setMessage(compileErr.output)
+	if len(missing) > 0 {
+		c.setState(false)
+		c.AddError(errors.Errorf("test failed to compile, but the output did not match [%s]",
+			strings.Join(missing, ", ")))
+		return
+	}
+
+	c.setState(true)
+}
@@ -3,62 +3,184 @@
 package check
 
 import (
-	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
 )
 
 func compilerInterfaceFactoryTable() map[string]compilerFactory {
-	factory := func(path string) func() compiler {
+	factory := func(cc, cxx string) func() compiler {
 		return func() compiler {
 			return compileGCC{
-				bin: path,
+				bin: cc,
+				cxx: cxx,
 			}
 		}
 	}
 
-	return map[string]compilerFactory{
+	table := map[string]compilerFactory{
 		"compile-gcc-auto":     gccCompilerAuto,
-		"compile-gcc-system":   factory("gcc"),
-		"compile-toolchain-v2": factory("/opt/mongodbtoolchain/v2/bin/gcc"),
-		"compile-toolchain-v1": factory("/opt/mongodbtoolchain/v1/bin/gcc"),
-		"compile-toolchain-v0": factory("/opt/mongodbtoolchain/bin/gcc"),
+		"compile-gcc-system":   factory("gcc", "g++"),
+		"compile-clang-auto":   clangCompilerAuto,
+		"compile-clang-system": factory("clang", "clang++"),
 		// must define all windows compilers here so that
 		// configs can be shared by systems with disjoint sets of tasts.
 		"compile-visual-studio": undefinedCompileCheckFactory("compile-visual-studio"),
 	}
+
+	for _, tc := range registeredToolchains(toolchainRoot, installedToolchains) {
+		table["compile-toolchain-"+tc.name()] = factory(tc.path("gcc"), tc.path("g++"))
+
+		if tc.has("clang") {
+			table["compile-toolchain-clang-"+tc.name()] = factory(tc.path("clang"), tc.path("clang++"))
+		}
+	}
+
+	return table
 }
 
+// compileGCC drives gcc and clang, and other compilers with the same
+// command line interface, using the C++ driver (e.g. g++ or clang++)
+// for C++ sources.
 type compileGCC struct {
 	bin string
+	cxx string
+}
+
+// cxxDriverFor returns the C++ driver that is installed alongside a C
+// compiler, e.g. "g++-12" for "gcc-12" or "clang++" for "clang".
+func cxxDriverFor(cc string) string {
+	dir, base := filepath.Split(cc)
+	switch {
+	case strings.HasPrefix(base, "gcc"):
+		base = "g++" + strings.TrimPrefix(base, "gcc")
+	case strings.HasPrefix(base, "clang"):
+		base = "clang++" + strings.TrimPrefix(base, "clang")
+	case base == "cc":
+		base = "c++"
+	default:
+		return ""
+	}
+
+	return dir + base
 }
 
 func gccCompilerAuto() compiler {
-	c := compileGCC{}
+	return &newestCompiler{
+		fallback: compileGCC{bin: "gcc", cxx: "g++"},
+		patterns: append(toolchainPrograms(installedToolchains, "gcc"),
+			"/usr/bin/gcc",
+			"/usr/bin/gcc-[0-9]*",
+			"/usr/local/bin/gcc",
+			"/usr/local/bin/gcc-[0-9]*",
+		),
+	}
+}
 
-	paths := []string{
-		"/opt/mongodbtoolchain/v2/bin/gcc",
-		"/opt/mongodbtoolchain/v1/bin/gcc",
-		"/opt/mongodbtoolchain/bin/gcc",
-		"/usr/bin/gcc",
-		"/usr/local/bin/gcc",
+func clangCompilerAuto() compiler {
+	return &newestCompiler{
+		fallback: compileGCC{bin: "clang", cxx: "clang++"},
+		patterns: append(toolchainPrograms(installedToolchains, "clang"),
+			"/usr/bin/clang",
+			"/usr/bin/clang-[0-9]*",
+			"/usr/lib/llvm-*/bin/clang",
+			"/usr/local/bin/clang",
+			"/usr/local/bin/clang-[0-9]*",
+		),
 	}
+}
 
-	for _, path := range paths {
-		if _, err := os.Stat(path); !os.IsNotExist(err) {
-			c.bin = path
-			break
+// newestCompiler selects the newest version of a compiler from the
+// paths that match its patterns, preferring earlier patterns (i.e.
+// the newest toolchain) between compilers with the same version.
+// Because this requires running every compiler, the selection happens
+// the first time that the check uses the compiler.
+type newestCompiler struct {
+	patterns []string
+	fallback compileGCC
+	once     sync.Once
+	selected compileGCC
+}
+
+func (c *newestCompiler) compiler() compileGCC {
+	c.once.Do(func() {
+		c.selected = c.fallback
+
+		var newest string
+		seen := map[string]bool{}
+		for _, pattern := range c.patterns {
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				continue
+			}
+
+			for _, path := range matches {
+				if seen[path] {
+					continue
+				}
+				seen[path] = true
+
+				version, err := compilerVersion(path)
+				if err != nil {
+					grip.Debug(err)
+					continue
+				}
+
+				if newest != "" {
+					cmp, err := compareLooseVersions(version, newest)
+					if err != nil || cmp <= 0 {
+						continue
+					}
+				}
+
+				newest = version
+				c.selected = compileGCC{bin: path, cxx: cxxDriverFor(path)}
+			}
 		}
+
+		grip.Debugf("selected compiler '%s' (version %s)", c.selected.bin, newest)
+	})
+
+	return c.selected
+}
+
+func (c *newestCompiler) Validate() error { return c.compiler().Validate() }
+func (c *newestCompiler) Compile(testBody string, opts compileOptions) error {
+	return c.compiler().Compile(testBody, opts)
+}
+func (c *newestCompiler) CompileAndRun(testBody string, opts compileOptions) (string, error) {
+	return c.compiler().CompileAndRun(testBody, opts)
+}
+
+var compilerVersionPattern = regexp.MustCompile(`\d+(?:\.\d+)+`)
+
+// compilerVersion reports a compiler's version from the first line of
+// its "--version" output, e.g. "gcc (Debian 12.2.0-14) 12.2.0" or
+// "Ubuntu clang version 14.0.0-1ubuntu1".
+func compilerVersion(bin string) (string, error) {
+	out, err := exec.Command(bin, "--version").Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "problem getting version of '%s'", bin)
 	}
 
-	if c.bin == "" {
-		c.bin = "gcc"
+	line := strings.SplitN(string(out), "\n", 2)[0]
+	if idx := strings.Index(line, "version "); idx >= 0 {
+		if version := compilerVersionPattern.FindString(line[idx:]); version != "" {
+			return version, nil
+		}
+	}
+
+	matches := compilerVersionPattern.FindAllString(line, -1)
+	if len(matches) == 0 {
+		return "", errors.Errorf("no version found for '%s' in '%s'", bin, line)
 	}
 
-	return c
+	return matches[len(matches)-1], nil
 }
 
 func (c compileGCC) Validate() error {
@@ -69,51 +191,80 @@ func (c compileGCC) Validate() error {
 	return nil
 }
 
-func (c compileGCC) Compile(testBody string, cFlags ...string) error {
-	outputName, sourceName, err := writeTestBody(testBody, "c")
-	outputName += ".o"
+// driver returns the compiler for the language of the test body.
+func (c compileGCC) driver(opts compileOptions) (string, error) {
+	if opts.language == "c++" {
+		if c.cxx == "" {
+			return "", errors.Errorf("no C++ compiler for '%s'", c.bin)
+		}
+		return c.cxx, nil
+	}
+
+	return c.bin, nil
+}
+
+// gccSourceExtensions are the extensions of additional files that
+// gcc-like compilers build, rather than only include.
+var gccSourceExtensions = []string{"c", "cc", "cpp", "cxx", "c++"}
+
+// Compile only compiles the test body, and any other source files, to
+// object files, so the ldflags and libraries are not used.
+func (c compileGCC) Compile(testBody string, opts compileOptions) error {
+	bin, err := c.driver(opts)
+	if err != nil {
+		return err
+	}
+
+	dir, sources, cleanup, err := writeTestWorkspace(opts, testBody, opts.extension(), gccSourceExtensions...)
 	if err != nil {
 		return errors.Wrap(err, "problem writing test to file")
 	}
-	defer os.Remove(outputName)
+	defer cleanup()
 
-	defer grip.CatchWarning(os.Remove(outputName))
-	argv := []string{"-Werror", "-o", outputName, "-c", sourceName}
-	argv = append(argv, cFlags...)
+	argv := []string{"-Werror", "-I" + dir, "-c"}
+	argv = append(argv, opts.cflags...)
+	argv = append(argv, sources...)
 
-	cmd := exec.Command(c.bin, argv...)
-	grip.Infof("running build command: %s %s", c.bin, strings.Join(cmd.Args, " "))
+	cmd := exec.Command(bin, argv...)
+	cmd.Dir = dir
+	grip.Infof("running build command: %s %s", bin, strings.Join(cmd.Args, " "))
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return errors.Wrapf(err, "problem compiling test body: %s", string(output))
+		return newCompileError(err, output)
 	}
 
 	return nil
 }
 
-func (c compileGCC) CompileAndRun(testBody string, cFlags ...string) (string, error) {
-	outputName, sourceName, err := writeTestBody(testBody, "c")
+func (c compileGCC) CompileAndRun(testBody string, opts compileOptions) (string, error) {
+	bin, err := c.driver(opts)
+	if err != nil {
+		return "", err
+	}
+
+	dir, sources, cleanup, err := writeTestWorkspace(opts, testBody, opts.extension(), gccSourceExtensions...)
 	if err != nil {
 		return "", errors.Wrap(err, "problem writing test to file")
 	}
-	defer os.Remove(outputName)
+	defer cleanup()
 
-	argv := []string{"-Werror", "-o", outputName, sourceName}
-	argv = append(argv, cFlags...)
+	outputName := filepath.Join(dir, "test")
 
-	cmd := exec.Command(c.bin, argv...)
-	grip.Infof("running build command: %s %s", c.bin, strings.Join(cmd.Args, " "))
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return string(out), errors.Wrap(err, "problem compiling test")
+	argv := []string{"-Werror", "-I" + dir, "-o", outputName}
+	argv = append(argv, opts.cflags...)
+	argv = append(argv, sources...)
+	for _, lib := range opts.libraries {
+		argv = append(argv, "-l"+lib)
 	}
+	argv = append(argv, opts.ldflags...)
 
-	cmd = exec.Command(outputName)
-	grip.Infof("running test command: %s", strings.Join(cmd.Args, " "))
-	out, err = cmd.CombinedOutput()
+	cmd := exec.Command(bin, argv...)
+	cmd.Dir = dir
+	grip.Infof("running build command: %s %s", bin, strings.Join(cmd.Args, " "))
+	out, err := cmd.CombinedOutput()
 	if err != nil {
-		return string(out), errors.Wrap(err, "problem running test program")
+		return string(out), newCompileError(err, out)
 	}
 
-	return strings.Trim(string(out), "\r\t\n "), nil
+	return runTestProgram(outputName, opts)
 }
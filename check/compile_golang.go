@@ -3,6 +3,8 @@ package check
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/mongodb/grip"
@@ -10,57 +12,64 @@ import (
 )
 
 func goCompilerIterfaceFactoryTable() map[string]compilerFactory {
-	systemPath := os.Getenv("PATH")
-
 	factory := func(path string, envPath string) compilerFactory {
 		return func() compiler {
-			c := compileGolang{
-				bin: path,
-			}
+			return newCompileGolang(path, envPath)
+		}
+	}
 
-			if envPath != "" {
-				c.path = "PATH=" + strings.Join([]string{envPath, systemPath}, string(os.PathListSeparator))
-			}
+	table := map[string]compilerFactory{
+		"compile-go-auto":        goCompilerAuto,
+		"compile-opt-go-default": factory("/opt/go/bin/go", ""),
+		"compile-usr-local-go":   factory("/usr/local/go", ""),
+		"compile-user-local-go":  factory("/usr/bin/go", ""),
+	}
 
-			return c
+	for _, tc := range registeredToolchains(toolchainRoot, installedToolchains) {
+		if tc.version == 2 || tc.has("go") {
+			table["compile-toolchain-gccgo-"+tc.name()] = factory(tc.path("go"), tc.bin)
 		}
 	}
 
-	return map[string]compilerFactory{
-		"compile-go-auto":            goCompilerAuto,
-		"compile-opt-go-default":     factory("/opt/go/bin/go", ""),
-		"compile-toolchain-gccgo-v2": factory("/opt/mongodbtoolchain/v2/bin/go", "/opt/mongodbtoolchain/v2/bin"),
-		"compile-usr-local-go":       factory("/usr/local/go", ""),
-		"compile-user-local-go":      factory("/usr/bin/go", ""),
+	return table
+}
+
+// newCompileGolang returns a go compiler; gccgo, from the toolchain,
+// needs the rest of the toolchain in the PATH.
+func newCompileGolang(path, envPath string) compileGolang {
+	c := compileGolang{
+		bin: path,
+	}
+
+	if envPath != "" {
+		c.path = "PATH=" + strings.Join([]string{envPath, os.Getenv("PATH")}, string(os.PathListSeparator))
 	}
+
+	return c
 }
 
 func goCompilerAuto() compiler {
 	paths := [][]string{
 		[]string{"/opt/go/bin/go", ""},
-		[]string{"/opt/mongodbtoolchain/v2/bin/go", "/opt/mongodbtoolchain/v2/bin"},
+	}
+
+	for _, path := range toolchainPrograms(installedToolchains, "go") {
+		paths = append(paths, []string{path, filepath.Dir(path)})
+	}
+
+	paths = append(paths,
 		[]string{"/usr/bin/go", ""},
 		[]string{"/usr/local/go/bin/go", ""},
 		[]string{"/usr/local/bin/go", ""},
-	}
-	c := compileGolang{}
+	)
 
 	for _, path := range paths {
 		if _, err := os.Stat(path[0]); !os.IsNotExist(err) {
-			c.bin = path[0]
-			if path[1] != "" {
-				c.path = path[1]
-
-			}
-			break
+			return newCompileGolang(path[0], path[1])
 		}
 	}
 
-	if c.bin == "" {
-		c.bin = "go"
-	}
-
-	return c
+	return compileGolang{bin: "go"}
 }
 
 type compileGolang struct {
@@ -80,44 +89,49 @@ func (c compileGolang) Validate() error {
 	return nil
 }
 
-func (c compileGolang) Compile(testBody string, _ ...string) error {
-	_, source, err := writeTestBody(testBody, "go")
+// build compiles the test body, and any other go files, in the
+// workspace, and returns the path to the program, the compiler's
+// output, and a function to clean up the workspace.
+func (c compileGolang) build(testBody string, opts compileOptions) (string, string, func(), error) {
+	dir, sources, cleanup, err := writeTestWorkspace(opts, testBody, "go", "go")
 	if err != nil {
-		return errors.Wrap(err, "problem writing test to temporary file")
+		return "", "", cleanup, errors.Wrap(err, "problem writing test to temporary file")
 	}
-	defer os.Remove(source)
 
-	cmd := exec.Command(c.bin, "build", source)
+	program := filepath.Join(dir, "test")
+	if runtime.GOOS == "windows" {
+		program += ".exe"
+	}
+
+	cmd := exec.Command(c.bin, append([]string{"build", "-o", program}, sources...)...)
+	cmd.Dir = dir
 	if c.path != "" {
-		cmd.Env = []string{c.path}
+		cmd.Env = append(os.Environ(), c.path)
 	}
 
 	grip.Infof("running build command: %s", cmd.Args)
 
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		return errors.Wrapf(err, "problem compiling go test: %s", string(out))
+		return program, string(out), cleanup, newCompileError(err, out)
 	}
 
-	return nil
+	return program, string(out), cleanup, nil
 }
 
-func (c compileGolang) CompileAndRun(testBody string, _ ...string) (string, error) {
-	_, source, err := writeTestBody(testBody, "go")
-	if err != nil {
-		return "", errors.Wrap(err, "problem writing test to temporary file")
-	}
-	defer os.Remove(source)
+func (c compileGolang) Compile(testBody string, opts compileOptions) error {
+	_, _, cleanup, err := c.build(testBody, opts)
+	cleanup()
 
-	cmd := exec.Command(c.bin, "run", source)
-	grip.Infof("running script: %s", cmd.Args)
+	return err
+}
 
-	out, err := cmd.CombinedOutput()
-	output := string(out)
+func (c compileGolang) CompileAndRun(testBody string, opts compileOptions) (string, error) {
+	program, output, cleanup, err := c.build(testBody, opts)
+	defer cleanup()
 	if err != nil {
-		return output, errors.Wrapf(err, "problem running go program: %s", output)
+		return output, err
 	}
 
-	output = strings.Trim(output, "\r\t\n ")
-	return output, nil
+	return runTestProgram(program, opts)
 }
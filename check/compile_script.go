@@ -3,22 +3,25 @@ package check
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
-	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
 )
 
-func scriptCompilerInterfaceFactoryTable() map[string]compilerFactory {
-	factory := func(path string) compilerFactory {
-		return func() compiler {
-			return &compileScript{
+// scriptInterpreterTable holds the interpreters for the script checks
+// with fixed names, which are aliases for the "run-script-output"
+// and, for "-script" names, "run-script-succeeds" checks.
+func scriptInterpreterTable() map[string]func() compileScript {
+	factory := func(path string) func() compileScript {
+		return func() compileScript {
+			return compileScript{
 				bin: path,
 			}
 		}
 	}
 
-	return map[string]compilerFactory{
+	table := map[string]func() compileScript{
 		"run-program-python-auto":      pythonCompilerAuto,
 		"run-program-system-python":    factory("python"),
 		"run-program-system-python2":   factory("python2"),
@@ -30,20 +33,32 @@ func scriptCompilerInterfaceFactoryTable() map[string]compilerFactory {
 		"run-dash-script":              factory("/bin/dash"),
 		"run-zsh-script":               factory("/bin/zsh"),
 	}
+
+	for _, tc := range installedToolchains {
+		if python := toolchainPrograms([]toolchain{tc}, "python3", "python"); len(python) > 0 {
+			table["run-program-toolchain-python-"+tc.name()] = factory(python[0])
+		}
+	}
+
+	return table
 }
 
+// compileScript runs scripts with an interpreter, and its arguments,
+// from a file with the extension. Without an extension, the extension
+// is based on the interpreter.
 type compileScript struct {
-	bin string
+	bin  string
+	args []string
+	ext  string
 }
 
-func pythonCompilerAuto() compiler {
+func pythonCompilerAuto() compileScript {
 	c := compileScript{}
 
-	paths := []string{
-		"/opt/mongodbtoolchain/v2/bin/python",
+	paths := append(toolchainPrograms(installedToolchains, "python3", "python"),
 		"/usr/local/bin/python",
 		"/usr/bin/python",
-	}
+	)
 
 	for _, path := range paths {
 		if _, err := os.Stat(path); !os.IsNotExist(err) {
@@ -59,6 +74,32 @@ func pythonCompilerAuto() compiler {
 	return c
 }
 
+// scriptExtensions maps interpreters, without version suffixes, to the
+// extensions of their scripts.
+var scriptExtensions = map[string]string{
+	"python":     "py",
+	"pypy":       "py",
+	"bash":       "sh",
+	"sh":         "sh",
+	"dash":       "sh",
+	"zsh":        "sh",
+	"ksh":        "sh",
+	"ruby":       "rb",
+	"perl":       "pl",
+	"node":       "js",
+	"pwsh":       "ps1",
+	"powershell": "ps1",
+}
+
+// scriptExtension returns the extension for scripts run by an
+// interpreter, e.g. "py" for "/usr/bin/python3.11".
+func scriptExtension(interpreter string) string {
+	name := strings.ToLower(strings.TrimSuffix(filepath.Base(interpreter), ".exe"))
+	name = strings.TrimRight(name, "0123456789.-")
+
+	return scriptExtensions[name]
+}
+
 func (c compileScript) Validate() error {
 	if c.bin == "" {
 		return errors.New("no script interpreter")
@@ -67,41 +108,37 @@ func (c compileScript) Validate() error {
 	return nil
 }
 
-func (c compileScript) Compile(testBody string, _ ...string) error {
-	_, sourceName, err := writeTestBody(testBody, "py")
-	if err != nil {
-		return errors.Wrap(err, "problem writing test")
+func (c compileScript) extension() string {
+	if c.ext != "" {
+		return strings.TrimPrefix(c.ext, ".")
 	}
 
-	defer os.Remove(sourceName)
-
-	cmd := exec.Command(c.bin, sourceName)
-	grip.Infof("running script script with command: %s", strings.Join(cmd.Args, " "))
+	return scriptExtension(c.bin)
+}
 
-	output, err := cmd.CombinedOutput()
+func (c compileScript) Compile(testBody string, opts compileOptions) error {
+	output, err := c.CompileAndRun(testBody, opts)
 	if err != nil {
-		return errors.Wrapf(err, "problem build/running test script %s: %s", sourceName,
-			string(output))
+		return errors.Wrapf(err, "problem build/running test script: %s", output)
 	}
 
 	return nil
 }
 
-func (c compileScript) CompileAndRun(testBody string, _ ...string) (string, error) {
-	_, sourceName, err := writeTestBody(testBody, "py")
+func (c compileScript) CompileAndRun(testBody string, opts compileOptions) (string, error) {
+	dir, sources, cleanup, err := writeTestWorkspace(opts, testBody, c.extension())
 	if err != nil {
 		return "", errors.Wrap(err, "problem writing test")
 	}
+	defer cleanup()
 
-	defer os.Remove(sourceName)
-
-	cmd := exec.Command(c.bin, sourceName)
-	grip.Infof("running script script with command: %s", strings.Join(cmd.Args, " "))
-	out, err := cmd.CombinedOutput()
-	output := string(out)
-	if err != nil {
-		return output, errors.Wrapf(err, "problem running test script %s", sourceName)
+	if len(sources) == 0 {
+		return "", errors.New("no script to run")
 	}
 
-	return strings.Trim(output, "\r\t\n "), nil
+	args := append(append([]string{}, c.args...), filepath.Join(dir, sources[0]))
+	cmd := exec.Command(c.bin, append(args, opts.args...)...)
+	cmd.Dir = dir
+
+	return runTestCommand(cmd, opts)
 }
@@ -19,17 +19,17 @@ func TestScriptCompilerImplementation(t *testing.T) {
 	assert.NoError(check.Validate())
 
 	// check that a basic hello world operation succeeds
-	err := check.Compile("print('hello world')")
+	err := check.Compile("print('hello world')", compileOptions{})
 	assert.NoError(err)
 
-	out, err := check.CompileAndRun("print('hello world!')")
+	out, err := check.CompileAndRun("print('hello world!')", compileOptions{})
 	assert.NoError(err)
 	assert.Equal("hello world!", out)
 
 	// check that we detect errors that fail
-	assert.Error(check.Compile("print('hi'); exit(1)"))
+	assert.Error(check.Compile("print('hi'); exit(1)", compileOptions{}))
 
-	out, err = check.CompileAndRun("print('hi'); exit(1)")
+	out, err = check.CompileAndRun("print('hi'); exit(1)", compileOptions{})
 	assert.Error(err)
 	assert.Equal("hi\n", out)
 }
@@ -3,9 +3,16 @@ package check
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
 	"testing"
 
+	"github.com/mongodb/amboy/registry"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCompileCheckFailsForInvalidCompilerInterfaces(t *testing.T) {
@@ -41,3 +48,472 @@ func TestCompilerImplementationsAreNotValidInDefaultState(t *testing.T) {
 	assert.NoError(goCompilerAuto().Validate())
 	assert.NoError(gccCompilerAuto().Validate())
 }
+
+func TestCompileCheckFlagsCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake pkg-config requires a POSIX shell")
+	}
+
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "greenbay-pkg-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	script := writeFakePkgConfig(t, dir)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "include"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "include", "fake.h"),
+		[]byte("#define FAKE_HEADER 1\n"), 0644))
+
+	source := `#include <stdio.h>
+#include <math.h>
+#include "fake.h"
+
+int main(int argc, char **argv) {
+    volatile double value = argc + 3.0;
+    printf("%s %d %.0f\n", GREETING, FAKE_HEADER, sqrt(value));
+    return 0;
+}
+`
+
+	check := &compileCheck{
+		Base:           NewBase("compile-and-run-gcc-auto", 0),
+		Source:         source,
+		CflagsCommand:  script + " --cflags fake",
+		LdflagsCommand: script + " --libs fake",
+		shouldRunCode:  true,
+		compiler:       gccCompilerAuto(),
+	}
+
+	opts, err := check.options()
+	assert.NoError(err)
+	assert.Equal([]string{"-lm"}, opts.ldflags)
+	assert.Len(opts.cflags, 2)
+
+	check.Run(context.Background())
+	assert.NoError(check.Error())
+	assert.True(check.Output().Passed)
+
+	// the compile-only check doesn't need the ldflags
+	check = &compileCheck{
+		Base:          NewBase("compile-gcc-auto", 0),
+		Source:        source,
+		CflagsCommand: script + " --cflags fake",
+		compiler:      gccCompilerAuto(),
+	}
+	check.Run(context.Background())
+	assert.NoError(check.Error())
+	assert.True(check.Output().Passed)
+
+	// without the cflags the header is missing
+	check = &compileCheck{
+		Base:     NewBase("compile-gcc-auto", 0),
+		Source:   source,
+		compiler: gccCompilerAuto(),
+	}
+	check.Run(context.Background())
+	assert.Error(check.Error())
+	assert.False(check.Output().Passed)
+
+	// and failing commands fail the check
+	check = &compileCheck{
+		Base:           NewBase("compile-and-run-gcc-auto", 0),
+		Source:         source,
+		CflagsCommand:  script + " --cflags fake",
+		LdflagsCommand: script + " --unknown",
+		shouldRunCode:  true,
+		compiler:       gccCompilerAuto(),
+	}
+	check.Run(context.Background())
+	assert.Error(check.Error())
+	assert.False(check.Output().Passed)
+}
+
+func TestCxxDriverFor(t *testing.T) {
+	assert := assert.New(t)
+
+	for cc, cxx := range map[string]string{
+		"gcc":                              "g++",
+		"/usr/bin/gcc-12":                  "/usr/bin/g++-12",
+		"/opt/mongodbtoolchain/v4/bin/gcc": "/opt/mongodbtoolchain/v4/bin/g++",
+		"clang":                            "clang++",
+		"/usr/lib/llvm-14/bin/clang":       "/usr/lib/llvm-14/bin/clang++",
+		"/usr/bin/clang-15":                "/usr/bin/clang++-15",
+		"cc":                               "c++",
+		"tcc":                              "",
+	} {
+		assert.Equal(cxx, cxxDriverFor(cc), cc)
+	}
+}
+
+func writeFakeCompiler(t *testing.T, path, versionLine string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, ioutil.WriteFile(path,
+		[]byte(fmt.Sprintf("#!/bin/sh\necho '%s'\necho 'Copyright'\n", versionLine)), 0755))
+}
+
+func TestNewestCompilerSelection(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake compilers require a POSIX shell")
+	}
+
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "greenbay-compilers")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFakeCompiler(t, filepath.Join(dir, "toolchain", "v3", "bin", "gcc"), "gcc (GCC) 8.5.0")
+	writeFakeCompiler(t, filepath.Join(dir, "toolchain", "v4", "bin", "gcc"), "gcc (GCC) 11.3.0")
+	writeFakeCompiler(t, filepath.Join(dir, "usr", "gcc"), "gcc (Debian 11.3.0-5) 11.3.0")
+	writeFakeCompiler(t, filepath.Join(dir, "usr", "gcc-9"), "gcc-9 (Ubuntu 9.4.0-1ubuntu1) 9.4.0")
+	writeFakeCompiler(t, filepath.Join(dir, "usr", "clang"), "Apple clang version 15.0.0 (clang-1500.1.0.2.5)")
+
+	version, err := compilerVersion(filepath.Join(dir, "usr", "clang"))
+	assert.NoError(err)
+	assert.Equal("15.0.0", version)
+
+	version, err = compilerVersion(filepath.Join(dir, "usr", "gcc-9"))
+	assert.NoError(err)
+	assert.Equal("9.4.0", version)
+
+	_, err = compilerVersion(filepath.Join(dir, "does-not-exist"))
+	assert.Error(err)
+
+	// the newest version wins, and the toolchain wins ties
+	comp := &newestCompiler{
+		fallback: compileGCC{bin: "gcc", cxx: "g++"},
+		patterns: []string{
+			filepath.Join(dir, "toolchain", "v*", "bin", "gcc"),
+			filepath.Join(dir, "usr", "gcc"),
+			filepath.Join(dir, "usr", "gcc-[0-9]*"),
+		},
+	}
+	assert.NoError(comp.Validate())
+	assert.Equal(filepath.Join(dir, "toolchain", "v4", "bin", "gcc"), comp.compiler().bin)
+	assert.Equal(filepath.Join(dir, "toolchain", "v4", "bin", "g++"), comp.compiler().cxx)
+
+	comp = &newestCompiler{
+		fallback: compileGCC{bin: "clang", cxx: "clang++"},
+		patterns: []string{filepath.Join(dir, "missing", "clang")},
+	}
+	assert.Equal("clang", comp.compiler().bin)
+}
+
+func TestCompileCheckLanguages(t *testing.T) {
+	assert := assert.New(t)
+
+	cxxSource := `#include <iostream>
+#include <optional>
+
+int main() {
+    std::optional<int> value = 42;
+    std::cout << "value: " << *value << std::endl;
+    return 0;
+}
+`
+
+	for _, name := range []string{"gcc", "clang"} {
+		if _, err := exec.LookPath(name); err != nil {
+			continue
+		}
+
+		factory := compilerInterfaceFactoryTable()["compile-"+name+"-auto"]
+
+		check := &compileCheck{
+			Base:          NewBase("compile-and-run-"+name+"-auto", 0),
+			Source:        cxxSource,
+			Language:      "c++",
+			Cflags:        []string{"-std=c++17"},
+			shouldRunCode: true,
+			compiler:      factory(),
+		}
+		check.Run(context.Background())
+		assert.NoError(check.Error(), name)
+		assert.True(check.Output().Passed, name)
+
+		// C++ doesn't compile as C
+		check = &compileCheck{
+			Base:     NewBase("compile-"+name+"-auto", 0),
+			Source:   cxxSource,
+			compiler: factory(),
+		}
+		check.Run(context.Background())
+		assert.Error(check.Error(), name)
+		assert.False(check.Output().Passed, name)
+
+		program := &programOutputCheck{
+			Base:           NewBase("run-program-"+name+"-auto", 0),
+			Source:         cxxSource,
+			Language:       "c++",
+			ExpectedOutput: "value: 42",
+			compiler:       factory(),
+		}
+		program.Run(context.Background())
+		assert.NoError(program.Error(), name)
+		assert.True(program.Output().Passed, name)
+	}
+
+	check := &compileCheck{
+		Base:     NewBase("compile-gcc-auto", 0),
+		Source:   cxxSource,
+		Language: "fortran",
+		compiler: gccCompilerAuto(),
+	}
+	check.Run(context.Background())
+	assert.Error(check.Error())
+	assert.False(check.Output().Passed)
+
+	comp := compileGCC{bin: "tcc"}
+	assert.Error(comp.Compile(cxxSource, compileOptions{language: "c++"}))
+}
+
+func TestValidateTestFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(validateTestFiles(nil))
+	assert.NoError(validateTestFiles(map[string]string{"a.h": "", "include/b.h": "", "src/../c.c": ""}))
+
+	for _, name := range []string{"", "/etc/passwd", "../escape.h", "include/../../escape.h", ".."} {
+		assert.Error(validateTestFiles(map[string]string{name: ""}), name)
+	}
+}
+
+func TestWriteTestWorkspace(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, sources, cleanup, err := writeTestWorkspace(compileOptions{files: map[string]string{
+		"util.c":         "int util() { return 1; }",
+		"include/util.h": "int util();",
+		"src/more.cpp":   "",
+		"data/input.txt": "hello",
+		"notes.c.orig":   "",
+	}}, "int main() { return 0; }", "c", gccSourceExtensions...)
+	require.NoError(t, err)
+	defer cleanup()
+
+	assert.Equal([]string{"test.c", filepath.Join("src", "more.cpp"), "util.c"}, sources)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "include", "util.h"))
+	assert.NoError(err)
+	assert.Equal("int util();", string(data))
+
+	// files alone are enough, but there must be a source file
+	_, sources, cleanup, err = writeTestWorkspace(compileOptions{files: map[string]string{"main.c": "int main() { return 0; }"}}, "", "c", "c")
+	assert.NoError(err)
+	assert.Equal([]string{"main.c"}, sources)
+	cleanup()
+
+	_, _, _, err = writeTestWorkspace(compileOptions{files: map[string]string{"main.h": ""}}, "", "c", "c")
+	assert.Error(err)
+
+	// the check's workspace is not removed by the compiler
+	workspace, err := newTestWorkspace()
+	require.NoError(t, err)
+	defer os.RemoveAll(workspace)
+
+	dir, sources, cleanup, err = writeTestWorkspace(compileOptions{workspace: workspace}, "print('hi')", "py")
+	assert.NoError(err)
+	assert.Equal(workspace, dir)
+	assert.Equal([]string{"test.py"}, sources)
+	cleanup()
+	_, err = os.Stat(filepath.Join(workspace, "test.py"))
+	assert.NoError(err)
+}
+
+func TestCompileCheckMultipleFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires gcc")
+	}
+
+	assert := assert.New(t)
+
+	source := `#include <stdio.h>
+#include <string.h>
+#include "include/util.h"
+
+int main(int argc, char **argv) {
+    char line[64] = {0};
+    if (fgets(line, sizeof(line), stdin) == NULL) {
+        return 10;
+    }
+    line[strcspn(line, "\n")] = 0;
+
+    printf("%s %s %d\n", line, argc > 1 ? argv[1] : "none", root(16));
+    return argc > 2 ? 3 : 0;
+}
+`
+	files := map[string]string{
+		"include/util.h": "int root(int value);\n",
+		"util.c": `#include <math.h>
+#include "include/util.h"
+
+int root(int value) {
+    volatile double v = value;
+    return (int)sqrt(v);
+}
+`,
+	}
+
+	newCheck := func() *compileCheck {
+		return &compileCheck{
+			Base:          NewBase("compile-and-run-gcc-auto", 0),
+			Source:        source,
+			Files:         files,
+			Libraries:     []string{"m"},
+			Args:          []string{"first"},
+			Stdin:         "greetings\n",
+			shouldRunCode: true,
+			compiler:      gccCompilerAuto(),
+		}
+	}
+
+	check := newCheck()
+	check.Run(context.Background())
+	assert.NoError(check.Error())
+	assert.True(check.Output().Passed)
+
+	output, err := check.compiler.CompileAndRun(check.Source, compileOptions{
+		language:  "c",
+		files:     files,
+		libraries: []string{"m"},
+		args:      []string{"first"},
+		stdin:     "greetings\n",
+	})
+	assert.NoError(err)
+	assert.Equal("greetings first 4", output)
+
+	// the exit code must match
+	check = newCheck()
+	check.Args = []string{"first", "second"}
+	check.Run(context.Background())
+	assert.Error(check.Error())
+	assert.False(check.Output().Passed)
+
+	check = newCheck()
+	check.Args = []string{"first", "second"}
+	check.ExitCode = 3
+	check.Run(context.Background())
+	assert.NoError(check.Error())
+	assert.True(check.Output().Passed)
+
+	// compile errors fail, even when expecting a non-zero exit code
+	check = newCheck()
+	check.ExitCode = 1
+	check.Files = map[string]string{"include/util.h": files["include/util.h"]}
+	check.Run(context.Background())
+	assert.Error(check.Error())
+	assert.False(check.Output().Passed)
+
+	// compile only checks build all of the sources
+	check = newCheck()
+	check.shouldRunCode = false
+	check.Run(context.Background())
+	assert.NoError(check.Error())
+	assert.True(check.Output().Passed)
+
+	check = newCheck()
+	check.Files = map[string]string{"../util.c": files["util.c"]}
+	check.Run(context.Background())
+	assert.Error(check.Error())
+	assert.False(check.Output().Passed)
+
+	check = newCheck()
+	check.Source = ""
+	check.Files = nil
+	check.Run(context.Background())
+	assert.Error(check.Error())
+	assert.False(check.Output().Passed)
+}
+
+func TestGoCompileCheckExitCode(t *testing.T) {
+	assert := assert.New(t)
+
+	comp := goCompilerAuto()
+	if err := comp.Validate(); err != nil {
+		t.Skip("go is not installed:", err)
+	}
+
+	check := &compileCheck{
+		Base: NewBase("compile-and-run-go-auto", 0),
+		Source: `package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	fmt.Println(greeting(), os.Args[1])
+	os.Exit(4)
+}
+`,
+		Files:         map[string]string{"greeting.go": "package main\n\nfunc greeting() string { return \"hello\" }\n"},
+		Args:          []string{"world"},
+		ExitCode:      4,
+		shouldRunCode: true,
+		compiler:      comp,
+	}
+	check.Run(context.Background())
+	assert.NoError(check.Error())
+	assert.True(check.Output().Passed)
+
+	output, err := comp.CompileAndRun(check.Source, compileOptions{files: check.Files, args: check.Args})
+	assert.Error(err)
+	assert.Equal("hello world\n", output)
+}
+
+func TestCompileFailsChecks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires gcc")
+	}
+
+	assert := assert.New(t)
+
+	for _, name := range []string{"compile-gcc-auto-fails", "compile-gcc-system-fails", "compile-go-auto-fails"} {
+		factory, err := registry.GetJobFactory(name)
+		if assert.NoError(err, name) {
+			check := factory().(*compileCheck)
+			assert.True(check.shouldNotCompile, name)
+			assert.False(check.shouldRunCode, name)
+		}
+	}
+
+	unusedVariable := "int main() { int unused = 1; return 0; }\n"
+	missingHeader := "#include <greenbay_deprecated_header.h>\nint main() { return 0; }\n"
+
+	for idx, test := range []struct {
+		passes      bool
+		source      string
+		cflags      []string
+		diagnostics []string
+		compiler    compiler
+	}{
+		{true, missingHeader, nil, nil, gccCompilerAuto()},
+		{true, missingHeader, nil, []string{`greenbay_deprecated_header\.h`, `(?i)no such file`}, gccCompilerAuto()},
+		{false, missingHeader, nil, []string{`undeclared`}, gccCompilerAuto()},
+		{true, unusedVariable, []string{"-Wall"}, []string{`unused variable`}, gccCompilerAuto()},
+		{false, unusedVariable, nil, nil, gccCompilerAuto()},
+		{false, missingHeader, nil, []string{`(unclosed`}, gccCompilerAuto()},
+		// a missing compiler is a failure for the wrong reason
+		{false, missingHeader, nil, nil, compileGCC{bin: "/does/not/exist/gcc"}},
+		{false, missingHeader, nil, nil, undefinedCompileCheckFactory("compile-visual-studio")()},
+	} {
+		check := &compileCheck{
+			Base:             NewBase("compile-gcc-auto-fails", 0),
+			Source:           test.source,
+			Cflags:           test.cflags,
+			Diagnostics:      test.diagnostics,
+			shouldNotCompile: true,
+			compiler:         test.compiler,
+		}
+		check.Run(context.Background())
+
+		output := check.Output()
+		assert.True(output.Completed, "%d", idx)
+		assert.Equal(test.passes, output.Passed, "%d: %+v", idx, output)
+		if test.passes {
+			assert.NoError(check.Error(), "%d", idx)
+		} else {
+			assert.Error(check.Error(), "%d", idx)
+		}
+	}
+}
@@ -10,8 +10,8 @@ type undefinedCompileCheck struct {
 	name string
 }
 
-func (c *undefinedCompileCheck) Compile(_ string, _ ...string) error { return c.Validate() }
-func (c *undefinedCompileCheck) CompileAndRun(_ string, _ ...string) (string, error) {
+func (c *undefinedCompileCheck) Compile(_ string, _ compileOptions) error { return c.Validate() }
+func (c *undefinedCompileCheck) CompileAndRun(_ string, _ compileOptions) (string, error) {
 	err := c.Validate()
 	return err.Error(), err
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
@@ -23,10 +24,13 @@ func compilerInterfaceFactoryTable() map[string]compilerFactory {
 	// we have to add all UNIX compilers to the test registry so
 	// that we can share configs between platforms with disjoint sets
 	// of registered tests.
-	for _, name := range []string{"compile-gcc-auto",
-		"compile-gcc-system", "compile-toolchain-v2",
-		"compile-toolchain-v1", "compile-toolchain-v0"} {
+	names := []string{"compile-gcc-auto", "compile-gcc-system",
+		"compile-clang-auto", "compile-clang-system"}
+	for _, tc := range registeredToolchains(toolchainRoot, installedToolchains) {
+		names = append(names, "compile-toolchain-"+tc.name())
+	}
 
+	for _, name := range names {
 		m[name] = undefinedCompileCheckFactory(name)
 	}
 
@@ -131,7 +135,7 @@ func (c *compileVS) findCl(envVars []string) (string, error) {
 	return "", errors.Errorf("Could not find cl in PATH")
 }
 
-func (c *compileVS) compileOp(filename, version string, cFlags ...string) error {
+func (c *compileVS) compileOp(dir string, sources []string, version string, cFlags []string, ldFlags []string) error {
 	// If no version was specified, just use the latest version.
 	if version == "" {
 		if len(c.versions) == 0 {
@@ -140,8 +144,9 @@ func (c *compileVS) compileOp(filename, version string, cFlags ...string) error
 		version = c.versions[len(c.versions)-1]
 	}
 
-	argv := []string{filename}
-	argv = append(argv, cFlags...)
+	argv := append([]string{}, cFlags...)
+	argv = append(argv, sources...)
+	argv = append(argv, ldFlags...)
 
 	envVars, ok := c.envVars[version]
 	if !ok {
@@ -155,9 +160,10 @@ func (c *compileVS) compileOp(filename, version string, cFlags ...string) error
 
 	cmd := exec.Command(clPath, argv...)
 	cmd.Env = envVars
+	cmd.Dir = dir
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return errors.Errorf("Compiler error (%v): %s", err, output)
+		return newCompileError(err, output)
 	}
 
 	return nil
@@ -167,53 +173,60 @@ func (c *compileVS) Validate() error {
 	return c.catcher.Resolve()
 }
 
-func (c *compileVS) Compile(testBody string, cFlags ...string) error {
-	outputName, sourceName, err := writeTestBody(testBody, "c")
+// vsSourceExtensions are the extensions of additional files that
+// Visual Studio builds, rather than only includes.
+var vsSourceExtensions = []string{"c", "cc", "cpp", "cxx"}
+
+func (c *compileVS) Compile(testBody string, opts compileOptions) error {
+	dir, sources, cleanup, err := writeTestWorkspace(opts, testBody, opts.extension(), vsSourceExtensions...)
 	if err != nil {
 		return fmt.Errorf("Error creating test body file: %v", err)
 	}
-	defer os.Remove(sourceName)
+	defer cleanup()
 
-	argv := []string{fmt.Sprintf("/Fo%s", outputName)}
-	argv = append(argv, cFlags...)
+	argv := []string{
+		fmt.Sprintf("/Fo%s\\", dir), // Put .obj files in the test directory
+		fmt.Sprintf("/I%s", dir),
+	}
+	argv = append(argv, opts.cflags...)
 	argv = append(argv, "/c")
 
-	err = c.compileOp(sourceName, "", argv...)
+	err = c.compileOp(dir, sources, "", argv, nil)
 	if err != nil {
 		return errors.Wrap(err, "problem compiling software")
 	}
-	defer grip.CatchWarning(os.Remove(fmt.Sprintf("%s.obj", outputName)))
 
 	return nil
 }
 
-func (c *compileVS) CompileAndRun(testBody string, cFlags ...string) (string, error) {
-	outputName, sourceName, err := writeTestBody(testBody, "c")
+func (c *compileVS) CompileAndRun(testBody string, opts compileOptions) (string, error) {
+	dir, sources, cleanup, err := writeTestWorkspace(opts, testBody, opts.extension(), vsSourceExtensions...)
 	if err != nil {
 		return "", errors.Wrap(err, "problem writing test to file")
 	}
+	defer cleanup()
 
-	defer os.Remove(sourceName)
-	defer os.Remove(fmt.Sprintf("%s.obj", outputName))
-
+	outputName := filepath.Join(dir, "test.exe")
 	argv := []string{
-		fmt.Sprintf("/Fo%s", outputName), // Set .obj output name
+		fmt.Sprintf("/Fo%s\\", dir),      // Put .obj files in the test directory
 		fmt.Sprintf("/Fe%s", outputName), // Set .exe output name
+		fmt.Sprintf("/I%s", dir),
 	}
-	argv = append(argv, cFlags...)
-	err = c.compileOp(sourceName, "", argv...)
-	if err != nil {
-		return "", err
+	argv = append(argv, opts.cflags...)
+
+	ldflags := []string{}
+	for _, lib := range opts.libraries {
+		if !strings.HasSuffix(strings.ToLower(lib), ".lib") {
+			lib += ".lib"
+		}
+		ldflags = append(ldflags, lib)
 	}
-	outputName = fmt.Sprintf("%s.exe", outputName)
+	ldflags = append(ldflags, opts.ldflags...)
 
-	defer os.Remove(outputName)
-	out, err := exec.Command(outputName).CombinedOutput()
-	output := string(out)
+	err = c.compileOp(dir, sources, "", argv, ldflags)
 	if err != nil {
-		return output, errors.Wrap(err, "problem running test program")
+		return "", err
 	}
 
-	output = strings.Trim(output, "\r\t\n ")
-	return output, nil
+	return runTestProgram(outputName, opts)
 }
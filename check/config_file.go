@@ -0,0 +1,368 @@
+package check
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ghodss/yaml"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "config-file-value"
+	registry.AddJobType(name, func() amboy.Job {
+		return &configFileValue{
+			Base: NewBase(name, 0), // (name, version)
+		}
+	})
+}
+
+// configFileValue parses a configuration file and asserts the value
+// found at a dotted path expression (e.g. "storage.dbPath") within
+// that file.
+type configFileValue struct {
+	FileName  string   `bson:"file_name" json:"file_name" yaml:"file_name"`
+	Format    string   `bson:"format" json:"format" yaml:"format"`
+	Path      string   `bson:"path" json:"path" yaml:"path"`
+	Assertion string   `bson:"assertion" json:"assertion" yaml:"assertion"`
+	Value     string   `bson:"value" json:"value" yaml:"value"`
+	Values    []string `bson:"values" json:"values" yaml:"values"`
+	*Base     `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+const (
+	configAssertExists   = "exists"
+	configAssertAbsent   = "absent"
+	configAssertEquals   = "equals"
+	configAssertOneOf    = "one-of"
+	configAssertContains = "contains"
+	configAssertMatches  = "matches"
+)
+
+func (c *configFileValue) validate() error {
+	if c.FileName == "" {
+		return errors.Errorf("no file specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	if _, ok := configFileParsers[c.Format]; !ok {
+		return errors.Errorf("config file format '%s' is not supported", c.Format)
+	}
+
+	if c.Path == "" {
+		return errors.Errorf("no path expression specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	switch c.Assertion {
+	case "":
+		grip.Debug("no assertion specified, checking that the value exists")
+		c.Assertion = configAssertExists
+	case configAssertExists, configAssertAbsent, configAssertEquals, configAssertContains:
+	case configAssertOneOf:
+		if len(c.Values) == 0 {
+			return errors.New("'one-of' assertions require a list of values")
+		}
+	case configAssertMatches:
+		if _, err := regexp.Compile(c.Value); err != nil {
+			return errors.Wrapf(err, "problem compiling pattern '%s'", c.Value)
+		}
+	default:
+		return errors.Errorf("assertion '%s' is not valid", c.Assertion)
+	}
+
+	return nil
+}
+
+func (c *configFileValue) Run(_ context.Context) {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	data, err := ioutil.ReadFile(c.FileName)
+	if err != nil {
+		c.setState(false)
+		c.AddError(errors.Wrapf(err, "problem reading config file '%s'", c.FileName))
+		return
+	}
+
+	doc, err := configFileParsers[c.Format](data)
+	if err != nil {
+		c.setState(false)
+		c.AddError(errors.Wrapf(err, "problem parsing %s file '%s'", c.Format, c.FileName))
+		return
+	}
+
+	value, exists := resolveConfigPath(doc, c.Path)
+	if c.Assertion == configAssertAbsent {
+		c.setState(!exists)
+		if exists {
+			msg := fmt.Sprintf("'%s' in '%s' is set to '%s'", c.Path, c.FileName, configValueString(value))
+			c.setMessage(msg)
+			c.AddError(errors.Errorf("'%s' is defined and should not be", c.Path))
+		}
+		return
+	}
+
+	if !exists {
+		c.setState(false)
+		c.AddError(errors.Errorf("'%s' is not defined in '%s'", c.Path, c.FileName))
+		return
+	}
+
+	actual := configValueString(value)
+	var result bool
+
+	switch c.Assertion {
+	case configAssertExists:
+		result = true
+	case configAssertEquals:
+		result = actual == c.Value
+	case configAssertOneOf:
+		for _, v := range c.Values {
+			if actual == v {
+				result = true
+				break
+			}
+		}
+	case configAssertContains:
+		for _, v := range configValueElements(value) {
+			if v == c.Value {
+				result = true
+				break
+			}
+		}
+	case configAssertMatches:
+		result = regexp.MustCompile(c.Value).MatchString(actual)
+	}
+
+	c.setState(result)
+	if !result {
+		msg := fmt.Sprintf("'%s' in '%s' is '%s', which does not satisfy %s %s",
+			c.Path, c.FileName, actual, c.Assertion, c.expectedString())
+		c.setMessage(msg)
+		c.AddError(errors.Errorf("check failed: %s", msg))
+	}
+}
+
+func (c *configFileValue) expectedString() string {
+	if c.Assertion == configAssertOneOf {
+		return fmt.Sprintf("[%s]", strings.Join(c.Values, ", "))
+	}
+
+	return fmt.Sprintf("'%s'", c.Value)
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Parsers and path resolution
+//
+////////////////////////////////////////////////////////////////////////
+
+// configFileParsers maps format names to functions that convert the
+// content of a file into a tree of maps, slices, and scalar values.
+var configFileParsers = map[string]func([]byte) (interface{}, error){
+	"json":      parseJSONConfig,
+	"yaml":      parseYAMLConfig,
+	"ini":       parseINIConfig,
+	"key-value": parseKeyValueConfig,
+	"directive": parseDirectiveConfig,
+}
+
+func parseJSONConfig(data []byte) (interface{}, error) {
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return out, nil
+}
+
+func parseYAMLConfig(data []byte) (interface{}, error) {
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return parseJSONConfig(data)
+}
+
+// parseINIConfig reads "[section]" headers and "key = value" pairs;
+// keys that appear before the first section are top-level values.
+func parseINIConfig(data []byte) (interface{}, error) {
+	out := map[string]interface{}{}
+	current := out
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section := map[string]interface{}{}
+			out[strings.TrimSpace(line[1:len(line)-1])] = section
+			current = section
+			continue
+		}
+
+		idx := strings.IndexAny(line, "=:")
+		if idx < 0 {
+			return nil, errors.Errorf("line %d is not a key/value pair: '%s'", lineNum, line)
+		}
+
+		current[strings.TrimSpace(line[:idx])] = unquote(strings.TrimSpace(line[idx+1:]))
+	}
+
+	return out, errors.WithStack(scanner.Err())
+}
+
+// parseKeyValueConfig reads shell-style "KEY=value" files, as found
+// in /etc/sysconfig and /etc/default.
+func parseKeyValueConfig(data []byte) (interface{}, error) {
+	out := map[string]interface{}{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+		idx := strings.Index(line, "=")
+		if idx < 0 {
+			return nil, errors.Errorf("line %d is not a key=value pair: '%s'", lineNum, line)
+		}
+
+		out[strings.TrimSpace(line[:idx])] = unquote(strings.TrimSpace(line[idx+1:]))
+	}
+
+	return out, errors.WithStack(scanner.Err())
+}
+
+// parseDirectiveConfig reads files made of "Keyword value" lines, as
+// in sshd_config. Keywords that appear more than once resolve to a
+// list of values.
+func parseDirectiveConfig(data []byte) (interface{}, error) {
+	out := map[string]interface{}{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// the keyword ends at the first space or tab, and the
+		// value (which may have spaces) is the rest of the line.
+		key, value := line, ""
+		if idx := strings.IndexFunc(line, unicode.IsSpace); idx >= 0 {
+			key, value = line[:idx], unquote(strings.TrimSpace(line[idx:]))
+		}
+
+		switch existing := out[key].(type) {
+		case nil:
+			out[key] = value
+		case string:
+			out[key] = []interface{}{existing, value}
+		case []interface{}:
+			out[key] = append(existing, value)
+		}
+	}
+
+	return out, errors.WithStack(scanner.Err())
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if (first == '"' || first == '\'') && first == last {
+			return value[1 : len(value)-1]
+		}
+	}
+
+	return value
+}
+
+// resolveConfigPath walks a parsed document following a dotted path
+// expression. Numeric segments index into lists.
+func resolveConfigPath(doc interface{}, path string) (interface{}, bool) {
+	current := doc
+
+	for _, segment := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			idx, err := strconv.Atoi(segment)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil, false
+			}
+			current = node[idx]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+func configValueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		return strings.Join(configValueElements(v), ",")
+	default:
+		out, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(out)
+	}
+}
+
+// configValueElements returns the members of a list value, or splits
+// a scalar value on commas (e.g. "net.bindIp: 127.0.0.1,10.0.0.1").
+func configValueElements(value interface{}) []string {
+	var out []string
+
+	if list, ok := value.([]interface{}); ok {
+		for _, v := range list {
+			out = append(out, configValueString(v))
+		}
+		return out
+	}
+
+	for _, v := range strings.Split(configValueString(value), ",") {
+		out = append(out, strings.TrimSpace(v))
+	}
+
+	return out
+}
@@ -0,0 +1,143 @@
+package check
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFixture(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "greenbay-config-")
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+
+	return f.Name()
+}
+
+func TestConfigFileParsers(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		format string
+		data   string
+		path   string
+		value  string
+	}{
+		{"json", `{"net": {"port": 27017, "ipv6": true}}`, "net.port", "27017"},
+		{"json", `{"net": {"port": 27017, "ipv6": true}}`, "net.ipv6", "true"},
+		{"yaml", "storage:\n  dbPath: /var/lib/mongo\n", "storage.dbPath", "/var/lib/mongo"},
+		{"yaml", "hosts:\n  - a\n  - b\n", "hosts.1", "b"},
+		{"ini", "top = 1\n[main]\n; comment\ngpgcheck = 1\nname=\"base\"\n", "main.name", "base"},
+		{"ini", "top = 1\n[main]\n", "top", "1"},
+		{"key-value", "# comment\nexport OPTIONS=\"-f /etc/mongod.conf\"\nUSER=mongod\n", "OPTIONS", "-f /etc/mongod.conf"},
+		{"directive", "Port 22\nPermitRootLogin no\n", "PermitRootLogin", "no"},
+		{"directive", "AcceptEnv LANG\nAcceptEnv LC_*\n", "AcceptEnv", "LANG,LC_*"},
+		{"directive", "Banner\t/etc/issue.net\nMatch\tUser anoncvs Address 10.0.0.1\n", "Match", "User anoncvs Address 10.0.0.1"},
+		{"directive", "Port \t 22\nUsePAM\n", "Port", "22"},
+	}
+
+	for _, c := range cases {
+		doc, err := configFileParsers[c.format]([]byte(c.data))
+		if !assert.NoError(err, c.format) {
+			continue
+		}
+
+		value, ok := resolveConfigPath(doc, c.path)
+		assert.True(ok, c.path)
+		assert.Equal(c.value, configValueString(value), c.path)
+
+		_, ok = resolveConfigPath(doc, c.path+".DOES-NOT-EXIST")
+		assert.False(ok)
+	}
+
+	_, err := parseJSONConfig([]byte("{"))
+	assert.Error(err)
+	_, err = parseKeyValueConfig([]byte("NOT A PAIR"))
+	assert.Error(err)
+}
+
+func TestConfigFileValueCheck(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	fn := writeConfigFixture(t, "net:\n  bindIp: 127.0.0.1,10.0.0.1\n  port: 27017\nstorage:\n  dbPath: /data/db\n")
+	defer os.Remove(fn)
+
+	cases := []struct {
+		path      string
+		assertion string
+		value     string
+		values    []string
+		expected  bool
+	}{
+		{"storage.dbPath", "", "", nil, true},
+		{"storage.journal", "", "", nil, false},
+		{"storage.journal", "absent", "", nil, true},
+		{"storage.dbPath", "absent", "", nil, false},
+		{"net.port", "equals", "27017", nil, true},
+		{"net.port", "equals", "27018", nil, false},
+		{"storage.dbPath", "one-of", "", []string{"/data/db", "/var/lib/mongo"}, true},
+		{"storage.dbPath", "one-of", "", []string{"/var/lib/mongo"}, false},
+		{"net.bindIp", "contains", "10.0.0.1", nil, true},
+		{"net.bindIp", "contains", "0.0.0.0", nil, false},
+		{"storage.dbPath", "matches", "^/data/", nil, true},
+		{"storage.dbPath", "matches", "^/var/", nil, false},
+	}
+
+	for _, c := range cases {
+		check := &configFileValue{
+			FileName:  fn,
+			Format:    "yaml",
+			Path:      c.path,
+			Assertion: c.assertion,
+			Value:     c.value,
+			Values:    c.values,
+			Base:      NewBase("config-file-value", 0),
+		}
+		check.Run(ctx)
+		output := check.Output()
+
+		assert.True(output.Completed)
+		assert.Equal(c.expected, output.Passed, "%s %s", c.path, c.assertion)
+		if c.expected {
+			assert.NoError(check.Error())
+		} else {
+			assert.Error(check.Error())
+		}
+	}
+}
+
+func TestConfigFileValueValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	check := &configFileValue{Base: NewBase("config-file-value", 0)}
+	assert.Error(check.validate())
+
+	check.FileName = "mongod.conf"
+	check.Format = "toml"
+	assert.Error(check.validate())
+
+	check.Format = "yaml"
+	assert.Error(check.validate())
+
+	check.Path = "net.port"
+	assert.NoError(check.validate())
+	assert.Equal("exists", check.Assertion)
+
+	check.Assertion = "one-of"
+	assert.Error(check.validate())
+
+	check.Assertion = "matches"
+	check.Value = "(("
+	assert.Error(check.validate())
+
+	check.Assertion = "neq"
+	assert.Error(check.validate())
+}
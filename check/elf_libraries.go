@@ -0,0 +1,370 @@
+package check
+
+import (
+	"bufio"
+	"context"
+	"debug/elf"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "elf-libraries-resolve"
+	registry.AddJobType(name, func() amboy.Job {
+		return &elfLibrariesResolve{
+			Base: NewBase(name, 0), // (name, version)
+		}
+	})
+}
+
+// elfLibrariesResolve reads the dynamic section of an ELF binary and
+// resolves every required shared library the way the dynamic loader
+// would, without executing the binary.
+type elfLibrariesResolve struct {
+	Binary      string `bson:"binary" json:"binary" yaml:"binary"`
+	LibraryPath string `bson:"ld_library_path" json:"ld_library_path" yaml:"ld_library_path"`
+	LdSoConf    string `bson:"ld_so_conf" json:"ld_so_conf" yaml:"ld_so_conf"`
+	DirectOnly  bool   `bson:"direct_only" json:"direct_only" yaml:"direct_only"`
+	*Base       `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+func (c *elfLibrariesResolve) validate() error {
+	if c.Binary == "" {
+		return errors.Errorf("no binary specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	if c.LibraryPath == "" {
+		c.LibraryPath = os.Getenv("LD_LIBRARY_PATH")
+	}
+
+	if c.LdSoConf == "" {
+		c.LdSoConf = "/etc/ld.so.conf"
+	}
+
+	return nil
+}
+
+func (c *elfLibrariesResolve) Run(_ context.Context) {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	confPaths, err := readLdSoConf(c.LdSoConf)
+	if err != nil {
+		grip.Warningf("problem reading '%s', using default library paths only: %+v", c.LdSoConf, err)
+	}
+
+	resolver := &elfLibraryResolver{
+		libraryPath: filepath.SplitList(c.LibraryPath),
+		confPaths:   confPaths,
+		recursive:   !c.DirectOnly,
+	}
+
+	unresolved, err := resolver.resolve(c.Binary)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	if len(unresolved) > 0 {
+		c.setState(false)
+		c.setMessage(unresolved)
+		c.AddError(errors.Errorf("%d libraries required by '%s' could not be resolved",
+			len(unresolved), c.Binary))
+		return
+	}
+
+	c.setState(true)
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Dynamic loader emulation
+//
+////////////////////////////////////////////////////////////////////////
+
+// elfLibraryResolver approximates the search order of the glibc
+// dynamic loader: DT_RPATH of the requiring object and then of the
+// objects that loaded it, up to the executable (when the requiring
+// object has no DT_RUNPATH), LD_LIBRARY_PATH, DT_RUNPATH, the
+// directories from ld.so.conf, and finally the default system
+// directories. Candidates that do not match the class and machine of
+// the requiring object are skipped.
+type elfLibraryResolver struct {
+	libraryPath  []string
+	confPaths    []string
+	defaultPaths []string
+	recursive    bool
+}
+
+type elfObject struct {
+	path    string
+	class   elf.Class
+	machine elf.Machine
+	needed  []string
+	rpath   []string
+	runpath []string
+}
+
+// inheritedRPath returns the DT_RPATH that the loader searches for the
+// libraries that an object's dependencies need, which it ignores when
+// the object has a DT_RUNPATH.
+func (obj *elfObject) inheritedRPath() []string {
+	if len(obj.runpath) > 0 {
+		return nil
+	}
+
+	return obj.rpath
+}
+
+// elfLoad is an object to resolve the dependencies of, with the
+// DT_RPATH of the objects that loaded it, nearest first.
+type elfLoad struct {
+	obj          *elfObject
+	loaderRPaths []string
+}
+
+func openELFObject(path string) (*elfObject, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem reading ELF file '%s'", path)
+	}
+	defer f.Close()
+
+	obj := &elfObject{
+		path:    path,
+		class:   f.Class,
+		machine: f.Machine,
+	}
+
+	if obj.needed, err = f.ImportedLibraries(); err != nil {
+		return nil, errors.Wrapf(err, "problem reading DT_NEEDED entries from '%s'", path)
+	}
+
+	origin := filepath.Dir(path)
+	lib := "lib"
+	if f.Class == elf.ELFCLASS64 {
+		lib = "lib64"
+	}
+
+	for _, tag := range []elf.DynTag{elf.DT_RPATH, elf.DT_RUNPATH} {
+		values, err := f.DynString(tag)
+		if err != nil {
+			return nil, errors.Wrapf(err, "problem reading %s from '%s'", tag, path)
+		}
+
+		var dirs []string
+		for _, value := range values {
+			for _, dir := range filepath.SplitList(value) {
+				dir = strings.Replace(dir, "${ORIGIN}", origin, -1)
+				dir = strings.Replace(dir, "$ORIGIN", origin, -1)
+				dir = strings.Replace(dir, "${LIB}", lib, -1)
+				dir = strings.Replace(dir, "$LIB", lib, -1)
+				dirs = append(dirs, dir)
+			}
+		}
+
+		if tag == elf.DT_RPATH {
+			obj.rpath = dirs
+		} else {
+			obj.runpath = dirs
+		}
+	}
+
+	return obj, nil
+}
+
+func (r *elfLibraryResolver) searchPaths(load elfLoad) []string {
+	var paths []string
+
+	obj := load.obj
+	if len(obj.runpath) == 0 {
+		paths = append(paths, obj.rpath...)
+		paths = append(paths, load.loaderRPaths...)
+	}
+	paths = append(paths, r.libraryPath...)
+	paths = append(paths, obj.runpath...)
+	paths = append(paths, r.confPaths...)
+
+	if r.defaultPaths != nil {
+		return append(paths, r.defaultPaths...)
+	}
+
+	if obj.class == elf.ELFCLASS64 {
+		paths = append(paths, "/lib64", "/usr/lib64")
+	}
+
+	return append(paths, "/lib", "/usr/lib")
+}
+
+// find returns the first candidate for a library, in the search
+// paths, that is an ELF object compatible with the requiring object.
+func (r *elfLibraryResolver) find(obj *elfObject, lib string, paths []string) (*elfObject, bool) {
+	candidates := []string{lib}
+	if !strings.Contains(lib, "/") {
+		candidates = nil
+		for _, dir := range paths {
+			if dir == "" {
+				continue
+			}
+			candidates = append(candidates, filepath.Join(dir, lib))
+		}
+	}
+
+	for _, path := range candidates {
+		dep, err := openELFObject(path)
+		if err != nil {
+			continue
+		}
+
+		if dep.class == obj.class && dep.machine == obj.machine {
+			return dep, true
+		}
+	}
+
+	return nil, false
+}
+
+// resolve returns a description of every library that could not be
+// found. Errors are only returned if the binary itself cannot be
+// read.
+func (r *elfLibraryResolver) resolve(binary string) ([]string, error) {
+	root, err := openELFObject(binary)
+	if err != nil {
+		return nil, err
+	}
+
+	var unresolved []string
+	seen := map[string]bool{}
+	queue := []elfLoad{{obj: root}}
+
+	for len(queue) > 0 {
+		load := queue[0]
+		obj := load.obj
+		queue = queue[1:]
+
+		paths := r.searchPaths(load)
+		loaderRPaths := uniqueDirectories(append(append([]string{}, obj.inheritedRPath()...), load.loaderRPaths...))
+
+		for _, lib := range obj.needed {
+			// objects can find the same library in different
+			// places, or not at all, depending on where they
+			// search, and the dependencies of the library
+			// search the DT_RPATH of the objects that loaded it.
+			key := strings.Join([]string{lib, strings.Join(paths, ":"), strings.Join(loaderRPaths, ":")}, "\x00")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			dep, ok := r.find(obj, lib, paths)
+			if !ok {
+				unresolved = append(unresolved, fmt.Sprintf("'%s' (needed by '%s') not found",
+					lib, obj.path))
+				continue
+			}
+
+			grip.Debugf("resolved '%s' (needed by '%s') to '%s'", lib, obj.path, dep.path)
+			if r.recursive {
+				queue = append(queue, elfLoad{obj: dep, loaderRPaths: loaderRPaths})
+			}
+		}
+	}
+
+	return unresolved, nil
+}
+
+// uniqueDirectories removes repeated directories, keeping the first,
+// so that dependency cycles don't grow the search path without end.
+func uniqueDirectories(dirs []string) []string {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if !seen[dir] {
+			seen[dir] = true
+			out = append(out, dir)
+		}
+	}
+
+	return out
+}
+
+// readLdSoConf returns the library directories listed in an
+// ld.so.conf file, following "include" directives.
+func readLdSoConf(fn string) ([]string, error) {
+	return readLdSoConfFile(fn, map[string]bool{})
+}
+
+// readLdSoConfFile reads an ld.so.conf file, and skips files that it
+// has already read, so that include cycles terminate.
+func readLdSoConfFile(fn string, visited map[string]bool) ([]string, error) {
+	if abs, err := filepath.Abs(fn); err == nil {
+		fn = abs
+	}
+
+	if visited[fn] {
+		return nil, nil
+	}
+	visited[fn] = true
+
+	file, err := os.Open(fn)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer file.Close()
+
+	var paths []string
+	catcher := grip.NewCatcher()
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] == "hwcap" {
+			continue
+		}
+
+		if fields[0] != "include" {
+			paths = append(paths, fields...)
+			continue
+		}
+
+		for _, pattern := range fields[1:] {
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(filepath.Dir(fn), pattern)
+			}
+
+			matches, err := filepath.Glob(pattern)
+			if err != nil {
+				catcher.Add(errors.Wrapf(err, "problem expanding '%s'", pattern))
+				continue
+			}
+
+			for _, match := range matches {
+				included, err := readLdSoConfFile(match, visited)
+				catcher.Add(err)
+				paths = append(paths, included...)
+			}
+		}
+	}
+	catcher.Add(scanner.Err())
+
+	return paths, catcher.Resolve()
+}
@@ -0,0 +1,153 @@
+package check
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadLdSoConfFollowsIncludes(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-ldso-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(os.Mkdir(filepath.Join(dir, "ld.so.conf.d"), 0755))
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, "ld.so.conf"),
+		[]byte("# comment\n/opt/a/lib\ninclude ld.so.conf.d/*.conf\nhwcap 0 nosegneg\n"), 0644))
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, "ld.so.conf.d", "one.conf"),
+		[]byte("/opt/b/lib # trailing\n/opt/c/lib\n"), 0644))
+
+	paths, err := readLdSoConf(filepath.Join(dir, "ld.so.conf"))
+	assert.NoError(err)
+	assert.Equal([]string{"/opt/a/lib", "/opt/b/lib", "/opt/c/lib"}, paths)
+
+	_, err = readLdSoConf(filepath.Join(dir, "DOES-NOT-EXIST"))
+	assert.Error(err)
+
+	// include cycles read each file once.
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, "ld.so.conf.d", "two.conf"),
+		[]byte("/opt/d/lib\ninclude ../ld.so.conf\n"), 0644))
+	paths, err = readLdSoConf(filepath.Join(dir, "ld.so.conf"))
+	assert.NoError(err)
+	assert.Equal([]string{"/opt/a/lib", "/opt/b/lib", "/opt/c/lib", "/opt/d/lib"}, paths)
+}
+
+func TestELFLibraryResolver(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("ELF resolution tests require a linux host")
+	}
+
+	assert := assert.New(t)
+	binary := "/bin/ls"
+	if _, err := os.Stat(binary); os.IsNotExist(err) {
+		t.Skip("no dynamically linked fixture binary")
+	}
+
+	confPaths, _ := readLdSoConf("/etc/ld.so.conf")
+	resolver := &elfLibraryResolver{confPaths: confPaths, recursive: true}
+	unresolved, err := resolver.resolve(binary)
+	assert.NoError(err)
+	assert.Len(unresolved, 0)
+
+	// without any search paths nothing should resolve.
+	resolver = &elfLibraryResolver{defaultPaths: []string{}}
+	unresolved, err = resolver.resolve(binary)
+	assert.NoError(err)
+	assert.NotEmpty(unresolved)
+
+	_, err = resolver.resolve("makefile")
+	assert.Error(err)
+}
+
+func TestELFLibrariesResolveCheck(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	check := &elfLibrariesResolve{Base: NewBase("elf-libraries-resolve", 0)}
+	assert.Error(check.validate())
+	check.Run(ctx)
+	assert.False(check.Output().Passed)
+	assert.Error(check.Error())
+
+	check = &elfLibrariesResolve{
+		Binary: "../makefile",
+		Base:   NewBase("elf-libraries-resolve", 0),
+	}
+	check.Run(ctx)
+	assert.False(check.Output().Passed)
+	assert.Error(check.Error())
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+
+	check = &elfLibrariesResolve{
+		Binary: "/bin/ls",
+		Base:   NewBase("elf-libraries-resolve", 0),
+	}
+	check.Run(ctx)
+	assert.True(check.Output().Passed, check.Output().Message)
+	assert.NoError(check.Error())
+}
+
+func TestELFLibraryResolverSearchPaths(t *testing.T) {
+	gcc, err := exec.LookPath("gcc")
+	if err != nil || runtime.GOOS != "linux" {
+		t.Skip("resolving built libraries requires gcc on linux")
+	}
+
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-elf-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	build := func(out, source string, args ...string) {
+		fn := out + ".c"
+		require.NoError(ioutil.WriteFile(fn, []byte(source), 0644))
+		output, err := exec.Command(gcc, append([]string{"-o", out, fn}, args...)...).CombinedOutput()
+		require.NoError(err, string(output))
+	}
+
+	libA, libB := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	require.NoError(os.Mkdir(libA, 0755))
+	require.NoError(os.Mkdir(libB, 0755))
+
+	build(filepath.Join(libB, "libb.so"), "int b(void) { return 1; }\n", "-shared", "-fPIC")
+	build(filepath.Join(libA, "liba.so"), "int b(void);\nint a(void) { return b(); }\n",
+		"-shared", "-fPIC", "-L"+libB, "-lb")
+	build(filepath.Join(dir, "rpath"), "int a(void);\nint main(void) { return a(); }\n",
+		"-L"+libA, "-L"+libB, "-la", "-Wl,--disable-new-dtags,-rpath,"+libA+":"+libB)
+
+	// liba.so doesn't have a search path, and finds libb.so with
+	// the DT_RPATH of the executable.
+	confPaths, _ := readLdSoConf("/etc/ld.so.conf")
+	resolver := &elfLibraryResolver{confPaths: confPaths, recursive: true}
+	unresolved, err := resolver.resolve(filepath.Join(dir, "rpath"))
+	assert.NoError(err)
+	assert.Len(unresolved, 0)
+
+	// but not when liba.so has a DT_RUNPATH, even though the
+	// executable finds libb.so itself.
+	build(filepath.Join(libA, "liba.so"), "int b(void);\nint a(void) { return b(); }\n",
+		"-shared", "-fPIC", "-L"+libB, "-lb", "-Wl,--enable-new-dtags,-rpath,"+filepath.Join(dir, "missing"))
+	build(filepath.Join(dir, "runpath"), "int a(void);\nint b(void);\nint main(void) { return a() + b(); }\n",
+		"-L"+libA, "-L"+libB, "-lb", "-la", "-Wl,--enable-new-dtags,-rpath,"+libA+":"+libB)
+
+	unresolved, err = resolver.resolve(filepath.Join(dir, "runpath"))
+	assert.NoError(err)
+	if assert.Len(unresolved, 1) {
+		assert.Contains(unresolved[0], "'libb.so' (needed by '"+filepath.Join(libA, "liba.so")+"')")
+	}
+}
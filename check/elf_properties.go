@@ -0,0 +1,376 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "elf-binary-properties"
+	registry.AddJobType(name, func() amboy.Job {
+		return &elfBinaryProperties{
+			Base: NewBase(name, 0), // (name, version)
+		}
+	})
+}
+
+// elfBinaryProperties asserts ABI properties of an ELF binary: the
+// target architecture, whether it is statically linked or position
+// independent, and the newest symbol version that it requires from
+// versioned libraries such as glibc and libstdc++.
+//
+// MaxSymbolVersions maps version prefixes (e.g. "GLIBC",
+// "GLIBCXX", "CXXABI") to the highest version the binary may require.
+type elfBinaryProperties struct {
+	Binary            string            `bson:"binary" json:"binary" yaml:"binary"`
+	Machine           string            `bson:"machine" json:"machine" yaml:"machine"`
+	Static            *bool             `bson:"static" json:"static" yaml:"static"`
+	PIE               *bool             `bson:"pie" json:"pie" yaml:"pie"`
+	MaxSymbolVersions map[string]string `bson:"max_symbol_versions" json:"max_symbol_versions" yaml:"max_symbol_versions"`
+	*Base             `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+// elfArchitecture is an ELF machine type, and the byte order for
+// architectures that are only distinguished by their byte order
+// (e.g. ppc64 and ppc64le), or nil for any byte order.
+type elfArchitecture struct {
+	name      string
+	machine   elf.Machine
+	byteOrder binary.ByteOrder
+}
+
+func (a elfArchitecture) matches(f *elf.File) bool {
+	return f.Machine == a.machine && (a.byteOrder == nil || f.ByteOrder == a.byteOrder)
+}
+
+// elfMachineAliases maps common architecture names, as used by uname,
+// GOARCH and distribution packages, to ELF machine types.
+var elfMachineAliases = map[string]elfArchitecture{
+	"x86_64":  {machine: elf.EM_X86_64},
+	"amd64":   {machine: elf.EM_X86_64},
+	"i386":    {machine: elf.EM_386},
+	"i686":    {machine: elf.EM_386},
+	"386":     {machine: elf.EM_386},
+	"aarch64": {machine: elf.EM_AARCH64},
+	"arm64":   {machine: elf.EM_AARCH64},
+	"arm":     {machine: elf.EM_ARM},
+	"ppc64le": {machine: elf.EM_PPC64, byteOrder: binary.LittleEndian},
+	"ppc64":   {machine: elf.EM_PPC64, byteOrder: binary.BigEndian},
+	"s390x":   {machine: elf.EM_S390},
+}
+
+func (c *elfBinaryProperties) validate() error {
+	if c.Binary == "" {
+		return errors.Errorf("no binary specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	if c.Machine != "" {
+		if _, err := parseELFMachine(c.Machine); err != nil {
+			return err
+		}
+	}
+
+	for prefix, version := range c.MaxSymbolVersions {
+		if _, err := parseLooseVersion(version); err != nil {
+			return errors.Wrapf(err, "invalid maximum version for '%s'", prefix)
+		}
+	}
+
+	return nil
+}
+
+func (c *elfBinaryProperties) Run(_ context.Context) {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	f, err := elf.Open(c.Binary)
+	if err != nil {
+		c.setState(false)
+		c.AddError(errors.Wrapf(err, "problem reading ELF file '%s'", c.Binary))
+		return
+	}
+	defer f.Close()
+
+	catcher := grip.NewCatcher()
+
+	isStatic, isPIE, err := elfLinkage(f)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	required, err := requiredSymbolVersions(f)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	if c.Machine != "" {
+		expected, _ := parseELFMachine(c.Machine)
+		if !expected.matches(f) {
+			catcher.Add(errors.Errorf("binary architecture is %s (%s), not %s",
+				f.Machine, f.Data, expected.name))
+		}
+	}
+
+	if c.Static != nil && *c.Static != isStatic {
+		catcher.Add(errors.Errorf("binary static=%t, expected static=%t", isStatic, *c.Static))
+	}
+
+	if c.PIE != nil && *c.PIE != isPIE {
+		catcher.Add(errors.Errorf("binary pie=%t, expected pie=%t", isPIE, *c.PIE))
+	}
+
+	for prefix, max := range c.MaxSymbolVersions {
+		actual, ok := required[prefix]
+		if !ok {
+			continue
+		}
+
+		if cmp, _ := compareLooseVersions(actual, max); cmp > 0 {
+			catcher.Add(errors.Errorf("binary requires %s_%s, newer than the maximum %s_%s",
+				prefix, actual, prefix, max))
+		}
+	}
+
+	var versions []string
+	for prefix, version := range required {
+		versions = append(versions, fmt.Sprintf("%s_%s", prefix, version))
+	}
+	sort.Strings(versions)
+
+	c.setMessage(fmt.Sprintf("binary '%s': machine=%s, static=%t, pie=%t, max_versions=[%s]",
+		c.Binary, f.Machine, isStatic, isPIE, strings.Join(versions, ", ")))
+
+	if catcher.HasErrors() {
+		c.setState(false)
+		c.AddError(catcher.Resolve())
+		return
+	}
+
+	c.setState(true)
+}
+
+func parseELFMachine(name string) (elfArchitecture, error) {
+	if arch, ok := elfMachineAliases[strings.ToLower(name)]; ok {
+		arch.name = name
+		return arch, nil
+	}
+
+	// also accept the debug/elf names, e.g. "EM_X86_64"
+	for m := elf.Machine(0); m < 512; m++ {
+		if m.String() == name {
+			return elfArchitecture{name: name, machine: m}, nil
+		}
+	}
+
+	return elfArchitecture{}, errors.Errorf("'%s' is not a known machine architecture", name)
+}
+
+// df1PIE is the DF_1_PIE flag in the DT_FLAGS_1 dynamic entry, which
+// debug/elf doesn't define.
+const df1PIE = 0x08000000
+
+// elfLinkage reports whether an ELF file is statically linked and
+// whether it is a position independent executable. Shared objects
+// (ET_DYN) are only executables when they have an interpreter or the
+// DF_1_PIE flag, so shared libraries are neither static nor PIE.
+// Executables are static when they don't have an interpreter, and
+// either don't have a dynamic section or don't need any shared
+// libraries, which includes static-PIE executables.
+func elfLinkage(f *elf.File) (bool, bool, error) {
+	hasInterp, hasDynamic := false, false
+	for _, prog := range f.Progs {
+		switch prog.Type {
+		case elf.PT_INTERP:
+			hasInterp = true
+		case elf.PT_DYNAMIC:
+			hasDynamic = true
+		}
+	}
+
+	needed, err := f.DynString(elf.DT_NEEDED)
+	if err != nil {
+		return false, false, errors.Wrap(err, "problem reading needed libraries")
+	}
+
+	flags, err := elfDynamicValues(f, elf.DT_FLAGS_1)
+	if err != nil {
+		return false, false, err
+	}
+
+	isPIE := false
+	if f.Type == elf.ET_DYN {
+		isPIE = hasInterp
+		for _, flag := range flags {
+			if flag&df1PIE != 0 {
+				isPIE = true
+			}
+		}
+	}
+
+	isExecutable := f.Type == elf.ET_EXEC || isPIE
+	isStatic := isExecutable && !hasInterp && (!hasDynamic || len(needed) == 0)
+
+	return isStatic, isPIE, nil
+}
+
+// elfDynamicValues returns the values of the entries in the dynamic
+// section (.dynamic) with the tag.
+func elfDynamicValues(f *elf.File, tag elf.DynTag) ([]uint64, error) {
+	ds := f.SectionByType(elf.SHT_DYNAMIC)
+	if ds == nil {
+		return nil, nil
+	}
+
+	data, err := ds.Data()
+	if err != nil {
+		return nil, errors.Wrap(err, "problem reading dynamic section")
+	}
+
+	var out []uint64
+	switch f.Class {
+	case elf.ELFCLASS32:
+		for len(data) >= 8 {
+			if elf.DynTag(f.ByteOrder.Uint32(data[0:4])) == tag {
+				out = append(out, uint64(f.ByteOrder.Uint32(data[4:8])))
+			}
+			data = data[8:]
+		}
+	case elf.ELFCLASS64:
+		for len(data) >= 16 {
+			if elf.DynTag(f.ByteOrder.Uint64(data[0:8])) == tag {
+				out = append(out, f.ByteOrder.Uint64(data[8:16]))
+			}
+			data = data[16:]
+		}
+	default:
+		return nil, errors.Errorf("unsupported ELF class %s", f.Class)
+	}
+
+	return out, nil
+}
+
+// elfString returns the null terminated string at the offset in a
+// string table.
+func elfString(table []byte, offset uint32) (string, bool) {
+	if int(offset) >= len(table) {
+		return "", false
+	}
+
+	end := bytes.IndexByte(table[offset:], 0)
+	if end < 0 {
+		return "", false
+	}
+
+	return string(table[offset : int(offset)+end]), true
+}
+
+// elfVersionNeeds reads the names of the versions in the version needs
+// table (.gnu.version_r), which is a list of Elf_Verneed entries, one
+// for each library, each with a list of Elf_Vernaux entries, one for
+// each version, with names in the linked string table (.dynstr).
+func elfVersionNeeds(f *elf.File) ([]string, error) {
+	vs := f.SectionByType(elf.SHT_GNU_VERNEED)
+	if vs == nil {
+		return nil, nil
+	}
+
+	if int(vs.Link) >= len(f.Sections) {
+		return nil, errors.New("version needs table has no string table")
+	}
+
+	data, err := vs.Data()
+	if err != nil {
+		return nil, errors.Wrap(err, "problem reading version needs table")
+	}
+
+	strtab, err := f.Sections[vs.Link].Data()
+	if err != nil {
+		return nil, errors.Wrap(err, "problem reading version needs string table")
+	}
+
+	order := f.ByteOrder
+
+	var out []string
+	for offset := 0; offset+16 <= len(data); {
+		// Elf_Verneed: vn_version (2), vn_cnt (2), vn_file (4),
+		// vn_aux (4), vn_next (4)
+		count := int(order.Uint16(data[offset+2:]))
+		aux := offset + int(order.Uint32(data[offset+8:]))
+		next := int(order.Uint32(data[offset+12:]))
+
+		for i := 0; i < count && aux+16 <= len(data); i++ {
+			// Elf_Vernaux: vna_hash (4), vna_flags (2),
+			// vna_other (2), vna_name (4), vna_next (4)
+			if name, ok := elfString(strtab, order.Uint32(data[aux+8:])); ok {
+				out = append(out, name)
+			}
+
+			auxNext := int(order.Uint32(data[aux+12:]))
+			if auxNext == 0 {
+				break
+			}
+			aux += auxNext
+		}
+
+		if next == 0 {
+			break
+		}
+		offset += next
+	}
+
+	return out, nil
+}
+
+// requiredSymbolVersions reads the version needs table (.gnu.version_r)
+// and returns the highest version required for each version prefix,
+// e.g. "GLIBC_2.14" and "GLIBC_2.17" produce {"GLIBC": "2.17"}.
+// Statically linked binaries have no version requirements.
+func requiredSymbolVersions(f *elf.File) (map[string]string, error) {
+	out := map[string]string{}
+
+	needs, err := elfVersionNeeds(f)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dep := range needs {
+		idx := strings.LastIndex(dep, "_")
+		if idx <= 0 {
+			continue
+		}
+
+		prefix, version := dep[:idx], dep[idx+1:]
+		if _, err := parseLooseVersion(version); err != nil {
+			// private versions like GLIBC_PRIVATE
+			continue
+		}
+
+		if current, ok := out[prefix]; !ok {
+			out[prefix] = version
+		} else if cmp, _ := compareLooseVersions(version, current); cmp > 0 {
+			out[prefix] = version
+		}
+	}
+
+	return out, nil
+}
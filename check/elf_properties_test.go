@@ -0,0 +1,190 @@
+package check
+
+import (
+	"context"
+	"debug/elf"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseELFMachine(t *testing.T) {
+	assert := assert.New(t)
+
+	for name, expected := range map[string]elf.Machine{
+		"x86_64":    elf.EM_X86_64,
+		"AMD64":     elf.EM_X86_64,
+		"aarch64":   elf.EM_AARCH64,
+		"EM_X86_64": elf.EM_X86_64,
+		"ppc64le":   elf.EM_PPC64,
+	} {
+		arch, err := parseELFMachine(name)
+		assert.NoError(err)
+		assert.Equal(expected, arch.machine, name)
+		assert.Equal(name, arch.name)
+	}
+
+	_, err := parseELFMachine("z80")
+	assert.Error(err)
+
+	// ppc64 and ppc64le only differ in their byte order.
+	bigEndian := &elf.File{FileHeader: elf.FileHeader{Machine: elf.EM_PPC64, ByteOrder: binary.BigEndian}}
+	littleEndian := &elf.File{FileHeader: elf.FileHeader{Machine: elf.EM_PPC64, ByteOrder: binary.LittleEndian}}
+
+	ppc64le, _ := parseELFMachine("ppc64le")
+	assert.True(ppc64le.matches(littleEndian))
+	assert.False(ppc64le.matches(bigEndian))
+
+	ppc64, _ := parseELFMachine("ppc64")
+	assert.True(ppc64.matches(bigEndian))
+	assert.False(ppc64.matches(littleEndian))
+
+	anyOrder, _ := parseELFMachine("EM_PPC64")
+	assert.True(anyOrder.matches(bigEndian))
+	assert.True(anyOrder.matches(littleEndian))
+}
+
+func TestELFBinaryPropertiesCheck(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	yes, no := true, false
+
+	check := &elfBinaryProperties{Base: NewBase("elf-binary-properties", 0)}
+	assert.Error(check.validate())
+
+	check.Binary = "/bin/ls"
+	check.MaxSymbolVersions = map[string]string{"GLIBC": "two"}
+	assert.Error(check.validate())
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+	if _, err := os.Stat("/bin/ls"); os.IsNotExist(err) {
+		return
+	}
+
+	machine := map[string]string{"amd64": "x86_64", "arm64": "aarch64"}[runtime.GOARCH]
+	if machine == "" {
+		return
+	}
+
+	cases := []struct {
+		check    *elfBinaryProperties
+		expected bool
+	}{
+		{&elfBinaryProperties{Machine: machine, Static: &no}, true},
+		{&elfBinaryProperties{Machine: "s390x"}, false},
+		{&elfBinaryProperties{Static: &yes}, false},
+		{&elfBinaryProperties{MaxSymbolVersions: map[string]string{"GLIBC": "99.0"}}, true},
+		{&elfBinaryProperties{MaxSymbolVersions: map[string]string{"GLIBC": "2.0"}}, false},
+	}
+
+	for idx, c := range cases {
+		c.check.Binary = "/bin/ls"
+		c.check.Base = NewBase("elf-binary-properties", 0)
+		c.check.Run(ctx)
+		output := c.check.Output()
+
+		assert.True(output.Completed)
+		assert.Equal(c.expected, output.Passed, "%d: %s", idx, output.Message)
+		if c.expected {
+			assert.NoError(c.check.Error())
+		} else {
+			assert.Error(c.check.Error())
+		}
+	}
+
+	f, err := elf.Open("/bin/ls")
+	assert.NoError(err)
+	defer f.Close()
+	versions, err := requiredSymbolVersions(f)
+	assert.NoError(err)
+	assert.Contains(versions, "GLIBC")
+}
+
+func TestELFLinkageOfSharedLibraries(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("test requires linux")
+	}
+
+	gcc, err := exec.LookPath("gcc")
+	if err != nil {
+		t.Skip("test requires gcc")
+	}
+
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-elf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	source := filepath.Join(dir, "lib.c")
+	require.NoError(t, ioutil.WriteFile(source, []byte("int answer(void) { return 42; }\n"), 0644))
+	lib := filepath.Join(dir, "libanswer.so")
+	out, err := exec.Command(gcc, "-shared", "-fPIC", "-o", lib, source).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	f, err := elf.Open(lib)
+	require.NoError(t, err)
+	defer f.Close()
+
+	// shared libraries are neither static nor executables
+	isStatic, isPIE, err := elfLinkage(f)
+	assert.NoError(err)
+	assert.False(isStatic)
+	assert.False(isPIE)
+
+	yes, no := true, false
+	check := &elfBinaryProperties{
+		Base:   NewBase("elf-binary-properties", 0),
+		Binary: lib,
+		Static: &no,
+		PIE:    &no,
+	}
+	check.Run(context.Background())
+	assert.True(check.Output().Passed, check.Output().Message)
+
+	check = &elfBinaryProperties{
+		Base:   NewBase("elf-binary-properties", 0),
+		Binary: lib,
+		PIE:    &yes,
+	}
+	check.Run(context.Background())
+	assert.False(check.Output().Passed, check.Output().Message)
+
+	// libraries that need other libraries aren't static either
+	require.NoError(t, ioutil.WriteFile(source, []byte("#include <math.h>\ndouble root(double x) { return sqrt(x); }\n"), 0644))
+	out, err = exec.Command(gcc, "-shared", "-fPIC", "-o", lib, source, "-lm").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	f, err = elf.Open(lib)
+	require.NoError(t, err)
+	defer f.Close()
+
+	isStatic, isPIE, err = elfLinkage(f)
+	assert.NoError(err)
+	assert.False(isStatic)
+	assert.False(isPIE)
+
+	// executables built as PIE have an interpreter
+	program := filepath.Join(dir, "program")
+	require.NoError(t, ioutil.WriteFile(source, []byte("int main(void) { return 0; }\n"), 0644))
+	out, err = exec.Command(gcc, "-fPIE", "-pie", "-o", program, source).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	f, err = elf.Open(program)
+	require.NoError(t, err)
+	defer f.Close()
+
+	isStatic, isPIE, err = elfLinkage(f)
+	assert.NoError(err)
+	assert.False(isStatic)
+	assert.True(isPIE)
+}
@@ -0,0 +1,203 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "environment-variable"
+	registry.AddJobType(name, func() amboy.Job {
+		return &environmentVariable{
+			Base: NewBase(name, 0), // (name, version)
+		}
+	})
+}
+
+const (
+	envSourceSelf       = "self"
+	envSourceLoginShell = "login-shell"
+	envSourceProcess    = "process"
+)
+
+// environmentVariable asserts the state of an environment variable
+// in greenbay's own environment, in the environment of a login
+// shell, or in the environment of another running process.
+type environmentVariable struct {
+	Variable  string `bson:"variable" json:"variable" yaml:"variable"`
+	Source    string `bson:"source" json:"source" yaml:"source"`
+	Shell     string `bson:"shell" json:"shell" yaml:"shell"`
+	PID       int    `bson:"pid" json:"pid" yaml:"pid"`
+	PIDFile   string `bson:"pid_file" json:"pid_file" yaml:"pid_file"`
+	Assertion string `bson:"assertion" json:"assertion" yaml:"assertion"`
+	Value     string `bson:"value" json:"value" yaml:"value"`
+	*Base     `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+func (c *environmentVariable) validate() error {
+	if c.Variable == "" {
+		return errors.Errorf("no variable specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	switch c.Source {
+	case "":
+		c.Source = envSourceSelf
+	case envSourceSelf:
+	case envSourceLoginShell:
+		if c.Shell == "" {
+			grip.Debug("no shell specified, using bash")
+			c.Shell = "bash"
+		}
+	case envSourceProcess:
+		if c.PID <= 0 && c.PIDFile == "" {
+			return errors.New("process environment checks require a pid or pid_file")
+		}
+	default:
+		return errors.Errorf("environment source '%s' is not valid", c.Source)
+	}
+
+	switch c.Assertion {
+	case "":
+		c.Assertion = configAssertExists
+	case configAssertExists, configAssertAbsent, configAssertEquals, configAssertContains:
+	case configAssertMatches:
+		if _, err := regexp.Compile(c.Value); err != nil {
+			return errors.Wrapf(err, "problem compiling pattern '%s'", c.Value)
+		}
+	default:
+		return errors.Errorf("assertion '%s' is not valid", c.Assertion)
+	}
+
+	return nil
+}
+
+func (c *environmentVariable) Run(_ context.Context) {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	env, err := c.getEnvironment()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	value, exists := env[c.Variable]
+	if c.Assertion == configAssertAbsent {
+		c.setState(!exists)
+		if exists {
+			c.setMessage(fmt.Sprintf("%s='%s' (source=%s)", c.Variable, value, c.Source))
+			c.AddError(errors.Errorf("variable '%s' is set and should not be", c.Variable))
+		}
+		return
+	}
+
+	if !exists {
+		c.setState(false)
+		c.AddError(errors.Errorf("variable '%s' is not set (source=%s)", c.Variable, c.Source))
+		return
+	}
+
+	var result bool
+	switch c.Assertion {
+	case configAssertExists:
+		result = true
+	case configAssertEquals:
+		result = value == c.Value
+	case configAssertContains:
+		for _, v := range filepath.SplitList(value) {
+			if v == c.Value {
+				result = true
+				break
+			}
+		}
+	case configAssertMatches:
+		result = regexp.MustCompile(c.Value).MatchString(value)
+	}
+
+	c.setState(result)
+	if !result {
+		msg := fmt.Sprintf("%s='%s' (source=%s) does not satisfy %s '%s'",
+			c.Variable, value, c.Source, c.Assertion, c.Value)
+		c.setMessage(msg)
+		c.AddError(errors.Errorf("check failed: %s", msg))
+	}
+}
+
+func (c *environmentVariable) getEnvironment() (map[string]string, error) {
+	switch c.Source {
+	case envSourceLoginShell:
+		out, err := exec.Command(c.Shell, "-lc", "env -0").Output()
+		if err != nil {
+			return nil, errors.Wrapf(err, "problem reading environment from '%s' login shell", c.Shell)
+		}
+
+		return parseEnvironment(out), nil
+	case envSourceProcess:
+		pid := c.PID
+		if c.PIDFile != "" {
+			data, err := ioutil.ReadFile(c.PIDFile)
+			if err != nil {
+				return nil, errors.Wrapf(err, "problem reading pid file '%s'", c.PIDFile)
+			}
+
+			pid, err = strconv.Atoi(strings.TrimSpace(string(data)))
+			if err != nil {
+				return nil, errors.Wrapf(err, "pid file '%s' does not contain a pid", c.PIDFile)
+			}
+		}
+
+		fn := filepath.Join("/proc", strconv.Itoa(pid), "environ")
+		data, err := ioutil.ReadFile(fn)
+		if err != nil {
+			return nil, errors.Wrapf(err, "problem reading environment for process %d", pid)
+		}
+
+		return parseEnvironment(data), nil
+	default:
+		out := map[string]string{}
+		for _, entry := range os.Environ() {
+			if idx := strings.Index(entry, "="); idx > 0 {
+				out[entry[:idx]] = entry[idx+1:]
+			}
+		}
+
+		return out, nil
+	}
+}
+
+// parseEnvironment converts a sequence of NUL separated KEY=value
+// entries, as in /proc/<pid>/environ, into a map.
+func parseEnvironment(data []byte) map[string]string {
+	out := map[string]string{}
+
+	for _, entry := range bytes.Split(data, []byte{0}) {
+		idx := bytes.IndexByte(entry, '=')
+		if idx <= 0 {
+			continue
+		}
+
+		out[string(entry[:idx])] = string(entry[idx+1:])
+	}
+
+	return out
+}
@@ -0,0 +1,141 @@
+package check
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEnvironment(t *testing.T) {
+	assert := assert.New(t)
+
+	env := parseEnvironment([]byte("A=1\x00B=x=y\x00MULTI=a\nb\x00=bad\x00\x00"))
+	assert.Len(env, 3)
+	assert.Equal("1", env["A"])
+	assert.Equal("x=y", env["B"])
+	assert.Equal("a\nb", env["MULTI"])
+}
+
+func TestEnvironmentVariableValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	check := &environmentVariable{Base: NewBase("environment-variable", 0)}
+	assert.Error(check.validate())
+
+	check.Variable = "PATH"
+	assert.NoError(check.validate())
+	assert.Equal("self", check.Source)
+	assert.Equal("exists", check.Assertion)
+
+	check.Source = "login-shell"
+	assert.NoError(check.validate())
+	assert.Equal("bash", check.Shell)
+
+	check.Source = "process"
+	assert.Error(check.validate())
+	check.PID = 1
+	assert.NoError(check.validate())
+
+	check.Source = "parent"
+	assert.Error(check.validate())
+
+	check.Source = "self"
+	check.Assertion = "one-of"
+	assert.Error(check.validate())
+}
+
+func TestEnvironmentVariableCheck(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	os.Setenv("GREENBAY_TEST_VALUE", "/opt/a:/opt/b")
+	defer os.Unsetenv("GREENBAY_TEST_VALUE")
+
+	cases := []struct {
+		variable  string
+		assertion string
+		value     string
+		expected  bool
+	}{
+		{"GREENBAY_TEST_VALUE", "", "", true},
+		{"GREENBAY_TEST_VALUE", "absent", "", false},
+		{"GREENBAY_DOES_NOT_EXIST", "absent", "", true},
+		{"GREENBAY_DOES_NOT_EXIST", "exists", "", false},
+		{"GREENBAY_TEST_VALUE", "equals", "/opt/a:/opt/b", true},
+		{"GREENBAY_TEST_VALUE", "equals", "/opt/a", false},
+		{"GREENBAY_TEST_VALUE", "contains", "/opt/b", true},
+		{"GREENBAY_TEST_VALUE", "contains", "/opt", false},
+		{"GREENBAY_TEST_VALUE", "matches", "^/opt/", true},
+		{"GREENBAY_TEST_VALUE", "matches", "^/usr/", false},
+	}
+
+	for _, c := range cases {
+		check := &environmentVariable{
+			Variable:  c.variable,
+			Assertion: c.assertion,
+			Value:     c.value,
+			Base:      NewBase("environment-variable", 0),
+		}
+		check.Run(ctx)
+		output := check.Output()
+
+		assert.True(output.Completed)
+		assert.Equal(c.expected, output.Passed, "%s %s", c.variable, c.assertion)
+		if c.expected {
+			assert.NoError(check.Error())
+		} else {
+			assert.Error(check.Error())
+		}
+	}
+}
+
+func TestEnvironmentVariableSources(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	if _, err := exec.LookPath("bash"); err == nil {
+		check := &environmentVariable{
+			Variable: "PATH",
+			Source:   "login-shell",
+			Base:     NewBase("environment-variable", 0),
+		}
+		check.Run(ctx)
+		assert.True(check.Output().Passed, check.Output().Error)
+	}
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+
+	pidFile, err := ioutil.TempFile("", "greenbay-pid-")
+	assert.NoError(err)
+	defer os.Remove(pidFile.Name())
+	_, err = pidFile.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+	assert.NoError(err)
+	assert.NoError(pidFile.Close())
+
+	check := &environmentVariable{
+		Variable: "PATH",
+		Source:   "process",
+		PIDFile:  pidFile.Name(),
+		Base:     NewBase("environment-variable", 0),
+	}
+	check.Run(ctx)
+	assert.True(check.Output().Passed, check.Output().Error)
+
+	check = &environmentVariable{
+		Variable: "PATH",
+		Source:   "process",
+		PID:      1 << 30,
+		Base:     NewBase("environment-variable", 0),
+	}
+	check.Run(ctx)
+	assert.False(check.Output().Passed)
+	assert.Error(check.Error())
+}
@@ -0,0 +1,214 @@
+package check
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "file-capabilities"
+	registry.AddJobType(name, func() amboy.Job {
+		return &fileCapabilities{
+			Base: NewBase(name, 0), // (name, version)
+		}
+	})
+}
+
+// fileCapabilities asserts that a file has, or lacks, linux file
+// capabilities, as stored in its "security.capability" extended
+// attribute (i.e. what setcap(8) writes and getcap(8) reports.)
+type fileCapabilities struct {
+	FileName  string   `bson:"file_name" json:"file_name" yaml:"file_name"`
+	Required  []string `bson:"required" json:"required" yaml:"required"`
+	Forbidden []string `bson:"forbidden" json:"forbidden" yaml:"forbidden"`
+	*Base     `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+// capabilityNames lists capabilities by number, as defined in
+// linux/capability.h.
+var capabilityNames = []string{
+	"cap_chown", "cap_dac_override", "cap_dac_read_search", "cap_fowner",
+	"cap_fsetid", "cap_kill", "cap_setgid", "cap_setuid", "cap_setpcap",
+	"cap_linux_immutable", "cap_net_bind_service", "cap_net_broadcast",
+	"cap_net_admin", "cap_net_raw", "cap_ipc_lock", "cap_ipc_owner",
+	"cap_sys_module", "cap_sys_rawio", "cap_sys_chroot", "cap_sys_ptrace",
+	"cap_sys_pacct", "cap_sys_admin", "cap_sys_boot", "cap_sys_nice",
+	"cap_sys_resource", "cap_sys_time", "cap_sys_tty_config", "cap_mknod",
+	"cap_lease", "cap_audit_write", "cap_audit_control", "cap_setfcap",
+	"cap_mac_override", "cap_mac_admin", "cap_syslog", "cap_wake_alarm",
+	"cap_block_suspend", "cap_audit_read", "cap_perfmon", "cap_bpf",
+	"cap_checkpoint_restore",
+}
+
+const (
+	vfsCapRevisionMask   = 0xFF000000
+	vfsCapRevision1      = 0x01000000
+	vfsCapRevision2      = 0x02000000
+	vfsCapRevision3      = 0x03000000
+	vfsCapFlagsEffective = 0x000001
+)
+
+// fileCapabilitySet is the decoded form of a security.capability
+// extended attribute.
+type fileCapabilitySet struct {
+	permitted   uint64
+	inheritable uint64
+	effective   bool
+}
+
+func (s fileCapabilitySet) has(capability int) bool {
+	return s.permitted&(1<<uint(capability)) != 0
+}
+
+func (s fileCapabilitySet) String() string {
+	var names []string
+	for idx, name := range capabilityNames {
+		if s.has(idx) {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return "none"
+	}
+
+	flags := "p"
+	if s.effective {
+		flags = "ep"
+	}
+
+	return fmt.Sprintf("%s=%s", strings.Join(names, ","), flags)
+}
+
+// decodeFileCapabilities parses the vfs_cap_data structure stored
+// in the security.capability extended attribute.
+func decodeFileCapabilities(data []byte) (fileCapabilitySet, error) {
+	var out fileCapabilitySet
+
+	if len(data) < 4 {
+		return out, errors.New("capability data is too short")
+	}
+
+	magic := binary.LittleEndian.Uint32(data[0:4])
+	out.effective = magic&vfsCapFlagsEffective != 0
+
+	var sets int
+	switch magic & vfsCapRevisionMask {
+	case vfsCapRevision1:
+		sets = 1
+	case vfsCapRevision2, vfsCapRevision3:
+		sets = 2
+	default:
+		return out, errors.Errorf("unknown capability revision 0x%x", magic&vfsCapRevisionMask)
+	}
+
+	if len(data) < 4+sets*8 {
+		return out, errors.New("capability data is truncated")
+	}
+
+	for i := 0; i < sets; i++ {
+		offset := 4 + i*8
+		shift := uint(32 * i)
+		out.permitted |= uint64(binary.LittleEndian.Uint32(data[offset:])) << shift
+		out.inheritable |= uint64(binary.LittleEndian.Uint32(data[offset+4:])) << shift
+	}
+
+	return out, nil
+}
+
+func capabilityNumber(name string) (int, error) {
+	name = strings.ToLower(name)
+	if !strings.HasPrefix(name, "cap_") {
+		name = "cap_" + name
+	}
+
+	for idx, n := range capabilityNames {
+		if n == name {
+			return idx, nil
+		}
+	}
+
+	return -1, errors.Errorf("'%s' is not a known capability", name)
+}
+
+func (c *fileCapabilities) validate() error {
+	if c.FileName == "" {
+		return errors.Errorf("no file specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	if len(c.Required) == 0 && len(c.Forbidden) == 0 {
+		return errors.Errorf("no capabilities specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	catcher := grip.NewCatcher()
+	for _, name := range append(append([]string{}, c.Required...), c.Forbidden...) {
+		_, err := capabilityNumber(name)
+		catcher.Add(err)
+	}
+
+	return catcher.Resolve()
+}
+
+func (c *fileCapabilities) Run(_ context.Context) {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	var caps fileCapabilitySet
+	data, exists, err := readCapabilityXattr(c.FileName)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	if exists {
+		caps, err = decodeFileCapabilities(data)
+		if err != nil {
+			c.setState(false)
+			c.AddError(errors.Wrapf(err, "problem decoding capabilities of '%s'", c.FileName))
+			return
+		}
+	}
+
+	var missing, extra []string
+	for _, name := range c.Required {
+		num, _ := capabilityNumber(name)
+		if !caps.has(num) {
+			missing = append(missing, capabilityNames[num])
+		}
+	}
+
+	for _, name := range c.Forbidden {
+		num, _ := capabilityNumber(name)
+		if caps.has(num) {
+			extra = append(extra, capabilityNames[num])
+		}
+	}
+
+	if len(missing) == 0 && len(extra) == 0 {
+		c.setState(true)
+		return
+	}
+
+	sort.Strings(missing)
+	sort.Strings(extra)
+	msg := fmt.Sprintf("'%s' has capabilities '%s'; missing=[%s], forbidden=[%s]",
+		c.FileName, caps, strings.Join(missing, ", "), strings.Join(extra, ", "))
+	c.setState(false)
+	c.setMessage(msg)
+	c.AddError(errors.Errorf("check failed: %s", msg))
+}
@@ -0,0 +1,81 @@
+package check
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// encodeFileCapabilities builds a revision 2 vfs_cap_data value,
+// which is the format setcap(8) writes.
+func encodeFileCapabilities(effective bool, caps ...int) []byte {
+	var permitted uint64
+	for _, c := range caps {
+		permitted |= 1 << uint(c)
+	}
+
+	magic := uint32(vfsCapRevision2)
+	if effective {
+		magic |= vfsCapFlagsEffective
+	}
+
+	data := make([]byte, 20)
+	binary.LittleEndian.PutUint32(data[0:], magic)
+	binary.LittleEndian.PutUint32(data[4:], uint32(permitted))
+	binary.LittleEndian.PutUint32(data[12:], uint32(permitted>>32))
+
+	return data
+}
+
+func TestDecodeFileCapabilities(t *testing.T) {
+	assert := assert.New(t)
+
+	netBind, err := capabilityNumber("cap_net_bind_service")
+	assert.NoError(err)
+	assert.Equal(10, netBind)
+
+	syslog, err := capabilityNumber("SYSLOG")
+	assert.NoError(err)
+	assert.Equal(34, syslog)
+
+	_, err = capabilityNumber("cap_fly")
+	assert.Error(err)
+
+	caps, err := decodeFileCapabilities(encodeFileCapabilities(true, netBind, syslog))
+	assert.NoError(err)
+	assert.True(caps.effective)
+	assert.True(caps.has(netBind))
+	assert.True(caps.has(syslog))
+	assert.False(caps.has(0))
+	assert.Equal("cap_net_bind_service,cap_syslog=ep", caps.String())
+
+	assert.Equal("none", fileCapabilitySet{}.String())
+
+	_, err = decodeFileCapabilities([]byte{1, 2})
+	assert.Error(err)
+
+	_, err = decodeFileCapabilities(encodeFileCapabilities(false)[:8])
+	assert.Error(err)
+
+	bad := encodeFileCapabilities(false)
+	bad[3] = 0x09
+	_, err = decodeFileCapabilities(bad)
+	assert.Error(err)
+}
+
+func TestFileCapabilitiesValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	check := &fileCapabilities{Base: NewBase("file-capabilities", 0)}
+	assert.Error(check.validate())
+
+	check.FileName = "/usr/bin/ping"
+	assert.Error(check.validate())
+
+	check.Required = []string{"cap_net_raw"}
+	assert.NoError(check.validate())
+
+	check.Forbidden = []string{"cap_fly"}
+	assert.Error(check.validate())
+}
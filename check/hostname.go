@@ -0,0 +1,201 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "hostname"
+	registry.AddJobType(name, func() amboy.Job {
+		return &hostnameCheck{
+			Base:     NewBase(name, 0), // (name, version)
+			resolver: systemHostResolver{},
+		}
+	})
+}
+
+// Internal interface for inspecting the host's name and address
+// resolution, so that we can inject fake implementations in tests.
+type hostResolver interface {
+	hostname() (string, error)
+	lookupHost(context.Context, string) ([]string, error)
+	lookupAddr(context.Context, string) ([]string, error)
+	localAddresses() ([]net.IP, error)
+}
+
+type systemHostResolver struct{}
+
+func (r systemHostResolver) hostname() (string, error) { return os.Hostname() }
+func (r systemHostResolver) lookupHost(ctx context.Context, host string) ([]string, error) {
+	return net.DefaultResolver.LookupHost(ctx, host)
+}
+func (r systemHostResolver) lookupAddr(ctx context.Context, addr string) ([]string, error) {
+	return net.DefaultResolver.LookupAddr(ctx, addr)
+}
+func (r systemHostResolver) localAddresses() ([]net.IP, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []net.IP
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			out = append(out, ipNet.IP)
+		}
+	}
+
+	return out, nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Implementation of the hostname check
+//
+////////////////////////////////////////////////////////////////////////
+
+// hostnameCheck asserts the short and fully qualified names of the
+// host and, optionally, that the host name resolves (through
+// /etc/hosts or DNS) to an address assigned to this host.
+type hostnameCheck struct {
+	ShortName       string `bson:"short_name" json:"short_name" yaml:"short_name"`
+	FQDN            string `bson:"fqdn" json:"fqdn" yaml:"fqdn"`
+	ResolvesLocally bool   `bson:"resolves_locally" json:"resolves_locally" yaml:"resolves_locally"`
+	Timeout         int    `bson:"timeout_secs" json:"timeout_secs" yaml:"timeout_secs"`
+	*Base           `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	resolver hostResolver
+}
+
+func (c *hostnameCheck) validate() error {
+	if c.ShortName == "" && c.FQDN == "" && !c.ResolvesLocally {
+		return errors.Errorf("no assertions specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	if c.Timeout <= 0 {
+		c.Timeout = 10
+	}
+
+	return nil
+}
+
+func (c *hostnameCheck) Run(ctx context.Context) {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(c.Timeout)*time.Second)
+	defer cancel()
+
+	name, err := c.resolver.hostname()
+	if err != nil {
+		c.setState(false)
+		c.AddError(errors.Wrap(err, "problem determining hostname"))
+		return
+	}
+	short := strings.SplitN(name, ".", 2)[0]
+
+	catcher := grip.NewCatcher()
+	if c.ShortName != "" && c.ShortName != short {
+		catcher.Add(errors.Errorf("short hostname is '%s', expected '%s'", short, c.ShortName))
+	}
+
+	addrs, lookupErr := c.resolver.lookupHost(ctx, name)
+
+	var fqdn string
+	if c.FQDN != "" {
+		fqdn = c.fullyQualifiedName(ctx, name, addrs)
+		if fqdn != c.FQDN {
+			catcher.Add(errors.Errorf("fully qualified hostname is '%s', expected '%s'", fqdn, c.FQDN))
+		}
+	}
+
+	if c.ResolvesLocally {
+		if lookupErr != nil {
+			catcher.Add(errors.Wrapf(lookupErr, "hostname '%s' does not resolve", name))
+		} else {
+			catcher.Add(c.checkLocal(name, addrs))
+		}
+	}
+
+	c.setMessage(fmt.Sprintf("hostname='%s', short='%s', fqdn='%s', addresses=[%s]",
+		name, short, fqdn, strings.Join(addrs, ", ")))
+
+	if catcher.HasErrors() {
+		c.setState(false)
+		c.AddError(catcher.Resolve())
+		return
+	}
+
+	c.setState(true)
+}
+
+// fullyQualifiedName mirrors "hostname -f": if the host name is not
+// already qualified, it returns the first qualified name that the
+// host's addresses reverse-resolve to.
+func (c *hostnameCheck) fullyQualifiedName(ctx context.Context, name string, addrs []string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+
+	for _, addr := range addrs {
+		names, err := c.resolver.lookupAddr(ctx, addr)
+		if err != nil {
+			grip.Debugf("problem reverse resolving '%s': %+v", addr, err)
+			continue
+		}
+
+		for _, n := range names {
+			n = strings.TrimSuffix(n, ".")
+			if strings.HasPrefix(n, name+".") {
+				return n
+			}
+		}
+	}
+
+	return name
+}
+
+func (c *hostnameCheck) checkLocal(name string, addrs []string) error {
+	local, err := c.resolver.localAddresses()
+	if err != nil {
+		return errors.Wrap(err, "problem listing local addresses")
+	}
+
+	for _, addr := range addrs {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			continue
+		}
+
+		// loopback addresses, like Debian's 127.0.1.1 entries
+		// in /etc/hosts, are local even if not assigned.
+		if ip.IsLoopback() {
+			return nil
+		}
+
+		for _, l := range local {
+			if ip.Equal(l) {
+				return nil
+			}
+		}
+	}
+
+	return errors.Errorf("hostname '%s' resolves to [%s], none of which are local addresses",
+		name, strings.Join(addrs, ", "))
+}
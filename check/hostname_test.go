@@ -0,0 +1,136 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type mockHostResolver struct {
+	name   string
+	hosts  map[string][]string
+	addrs  map[string][]string
+	local  []net.IP
+	failed bool
+}
+
+func (r *mockHostResolver) hostname() (string, error) {
+	if r.failed {
+		return "", errors.New("hostname failed")
+	}
+	return r.name, nil
+}
+
+func (r *mockHostResolver) lookupHost(_ context.Context, h string) ([]string, error) {
+	if out, ok := r.hosts[h]; ok {
+		return out, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func (r *mockHostResolver) lookupAddr(_ context.Context, a string) ([]string, error) {
+	if out, ok := r.addrs[a]; ok {
+		return out, nil
+	}
+	return nil, errors.New("no such address")
+}
+
+func (r *mockHostResolver) localAddresses() ([]net.IP, error) { return r.local, nil }
+
+type HostnameCheckSuite struct {
+	check    *hostnameCheck
+	resolver *mockHostResolver
+	require  *require.Assertions
+	suite.Suite
+}
+
+func TestHostnameCheckSuite(t *testing.T) {
+	suite.Run(t, new(HostnameCheckSuite))
+}
+
+func (s *HostnameCheckSuite) SetupSuite() {
+	s.require = s.Require()
+}
+
+func (s *HostnameCheckSuite) SetupTest() {
+	s.resolver = &mockHostResolver{
+		name:  "db1",
+		hosts: map[string][]string{"db1": []string{"10.0.0.5"}},
+		addrs: map[string][]string{"10.0.0.5": []string{"db1.example.net.", "db1"}},
+		local: []net.IP{net.ParseIP("10.0.0.5"), net.ParseIP("127.0.0.1")},
+	}
+	s.check = &hostnameCheck{
+		Base:     NewBase("hostname", 0),
+		resolver: s.resolver,
+	}
+}
+
+func (s *HostnameCheckSuite) run(expected bool) {
+	s.check.Run(context.Background())
+	output := s.check.Output()
+	s.True(output.Completed)
+	s.Equal(expected, output.Passed, output.Error)
+	if expected {
+		s.NoError(s.check.Error())
+	} else {
+		s.Error(s.check.Error())
+	}
+}
+
+func (s *HostnameCheckSuite) TestWithoutAssertionsCheckFails() {
+	s.Error(s.check.validate())
+	s.run(false)
+}
+
+func (s *HostnameCheckSuite) TestNamesMatch() {
+	s.check.ShortName = "db1"
+	s.check.FQDN = "db1.example.net"
+	s.check.ResolvesLocally = true
+	s.run(true)
+}
+
+func (s *HostnameCheckSuite) TestQualifiedHostnameIsUsedDirectly() {
+	s.resolver.name = "db1.corp.example.net"
+	s.check.ShortName = "db1"
+	s.check.FQDN = "db1.corp.example.net"
+	s.run(true)
+}
+
+func (s *HostnameCheckSuite) TestShortNameMismatchFails() {
+	s.check.ShortName = "db2"
+	s.run(false)
+}
+
+func (s *HostnameCheckSuite) TestFQDNMismatchFails() {
+	s.check.FQDN = "db1.other.net"
+	s.run(false)
+}
+
+func (s *HostnameCheckSuite) TestNonLocalAddressFails() {
+	s.resolver.local = []net.IP{net.ParseIP("10.0.0.6")}
+	s.check.ResolvesLocally = true
+	s.run(false)
+}
+
+func (s *HostnameCheckSuite) TestLoopbackAddressIsLocal() {
+	s.resolver.hosts["db1"] = []string{"127.0.1.1"}
+	s.resolver.local = nil
+	s.check.ResolvesLocally = true
+	s.run(true)
+}
+
+func (s *HostnameCheckSuite) TestUnresolvableNameFails() {
+	s.resolver.hosts = map[string][]string{}
+	s.check.ResolvesLocally = true
+	s.run(false)
+}
+
+func (s *HostnameCheckSuite) TestHostnameErrorFails() {
+	s.resolver.failed = true
+	s.check.ShortName = "db1"
+	s.run(false)
+}
@@ -3,11 +3,45 @@ package check
 func init() {
 	packageCheckerRegistry = map[string]packageChecker{
 		"yum":    packageCheckerFactory([]string{"yum", "list", "installed"}),
-		"dpkg":   packageCheckerFactory([]string{"dpkg", "-l"}),
+		"dpkg":   nativePackageChecker(dpkgDatabase, packageCheckerFactory([]string{"dpkg", "-l"})),
 		"brew":   packageCheckerFactory([]string{"brew", "list"}),
-		"pacman": packageCheckerFactory([]string{"pacman", "-Q"}),
-		"pip":    packageCheckerFactory([]string{"pip", "show"}),
+		"pacman": nativePackageChecker(pacmanDatabase, packageCheckerFactory([]string{"pacman", "-Q"})),
+		"pip":    nativePackageChecker(pipDatabase, packageCheckerFactory([]string{"pip", "show"})),
 		"gem":    packageCheckerFactory([]string{"gem", "list", "-i"}),
+		"apk":    nativePackageChecker(apkDatabase, packageCheckerFactory([]string{"apk", "info", "-e"})),
+		"zypper": packageCheckerFactory([]string{"rpm", "-q"}),
+		"conda":  versionPackageChecker("conda", commandVersioner("pep440", condaListCommand(""), parseCondaListVersions)),
+		"npm":    packageCheckerFactory([]string{"npm", "ls", "--global", "--depth=0"}),
+		"cargo":  versionPackageChecker("cargo", cargoVersioner),
+		"go":     versionPackageChecker("go", goToolsVersioner),
+	}
+
+	packageVersionerRegistry = map[string]packageVersioner{
+		"yum": packageVersionerFactory("rpm",
+			[]string{"rpm", "-q", "--qf", "%{EPOCH}:%{VERSION}-%{RELEASE}\n"}, parseRPMQueryVersions),
+		"dpkg": nativePackageVersioner(dpkgDatabase, packageVersionerFactory("dpkg",
+			[]string{"dpkg-query", "-W", "-f=${Status}\t${Version}\n"}, parseDpkgQueryVersions)),
+		"brew": packageVersionerFactory("rpm",
+			[]string{"brew", "list", "--versions"}, parseNameVersionsLine),
+		"pacman": nativePackageVersioner(pacmanDatabase, packageVersionerFactory("rpm",
+			[]string{"pacman", "-Q"}, parseNameVersionsLine)),
+		"pip": nativePackageVersioner(pipDatabase, packageVersionerFactory("pep440",
+			[]string{"pip", "show"}, parsePipShowVersion)),
+		"gem": packageVersionerFactory("gem",
+			[]string{"gem", "list", "-e"}, parseGemListVersions),
+		"apk": nativePackageVersioner(apkDatabase, packageVersionerFactory("apk",
+			[]string{"apk", "info", "-e", "-v"}, parseApkInfoVersions)),
+		"zypper": packageVersionerFactory("rpm",
+			[]string{"rpm", "-q", "--qf", "%{EPOCH}:%{VERSION}-%{RELEASE}\n"}, parseRPMQueryVersions),
+		"conda": commandVersioner("pep440", condaListCommand(""), parseCondaListVersions),
+		"npm":   npmVersioner,
+		"cargo": cargoVersioner,
+		"go":    goToolsVersioner,
+	}
+
+	packageEnvironmentRegistry = map[string]func(string) (packageChecker, packageVersioner){
+		"pip":   pipEnvironment,
+		"conda": condaEnvironment,
 	}
 
 	groupRequirementRegistry = map[string]GroupRequirements{
@@ -17,12 +51,12 @@ func init() {
 		"none": GroupRequirements{None: true},
 	}
 
-	registerPackageChecks()       // from package.go
-	registerPackageGroupChecks()  // from package_group.go
-	registerFileGroupChecks()     // from file_group_exists.go
-	registerCommandGroupChecks()  // from command_group.go
-	registerSystemLimitChecks()   // from limit.go
-	registerProgramChecks()       // from program.go
-	registerProgramReturnChecks() // from program_return.go
-	registerCompileChecks()       // from compile.go
+	registerPackageChecks()      // from package.go
+	registerPackageGroupChecks() // from package_group.go
+	registerFileGroupChecks()    // from file_group_exists.go
+	registerCommandGroupChecks() // from command_group.go
+	registerSystemLimitChecks()  // from limit.go
+	registerProgramChecks()      // from program.go
+	registerScriptChecks()       // from script.go
+	registerCompileChecks()      // from compile.go
 }
@@ -0,0 +1,158 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "system-locale"
+	registry.AddJobType(name, func() amboy.Job {
+		return &systemLocale{
+			Base:        NewBase(name, 0), // (name, version)
+			localeDir:   "/usr/lib/locale",
+			configFiles: []string{"/etc/locale.conf", "/etc/default/locale"},
+			listLocales: true,
+		}
+	})
+}
+
+// systemLocale asserts that locales are installed and that the
+// default locale is set as expected. The default locale is taken
+// from LC_ALL or LANG in the environment, and otherwise from the
+// system locale configuration file.
+type systemLocale struct {
+	Available []string `bson:"available" json:"available" yaml:"available"`
+	Default   string   `bson:"default" json:"default" yaml:"default"`
+	*Base     `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	localeDir   string
+	configFiles []string
+	listLocales bool
+}
+
+// normalizeLocale mirrors glibc's handling of codeset names, so that
+// "en_US.UTF-8" and "en_US.utf8" compare as equal.
+func normalizeLocale(name string) string {
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) == 1 {
+		return name
+	}
+
+	codeset := parts[1]
+	modifier := ""
+	if idx := strings.Index(codeset, "@"); idx >= 0 {
+		codeset, modifier = codeset[:idx], codeset[idx:]
+	}
+
+	codeset = strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(codeset))
+	return parts[0] + "." + codeset + modifier
+}
+
+func (c *systemLocale) Run(_ context.Context) {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if len(c.Available) == 0 && c.Default == "" {
+		c.setState(false)
+		c.AddError(errors.Errorf("no locales specified for '%s' (%s) check", c.ID(), c.Name()))
+		return
+	}
+
+	catcher := grip.NewCatcher()
+	installed := c.installedLocales()
+	for _, name := range c.Available {
+		if !installed[normalizeLocale(name)] {
+			catcher.Add(errors.Errorf("locale '%s' is not available", name))
+		}
+	}
+
+	var current, source string
+	if c.Default != "" {
+		current, source = c.defaultLocale()
+		if normalizeLocale(current) != normalizeLocale(c.Default) {
+			catcher.Add(errors.Errorf("default locale is '%s' (from %s), expected '%s'",
+				current, source, c.Default))
+		}
+	}
+
+	if catcher.HasErrors() {
+		var names []string
+		for name := range installed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		c.setState(false)
+		c.setMessage(fmt.Sprintf("default='%s', available=[%s]", current, strings.Join(names, ", ")))
+		c.AddError(catcher.Resolve())
+		return
+	}
+
+	c.setState(true)
+}
+
+// installedLocales reads compiled locale directories and, because
+// most distributions store locales in a single locale-archive file,
+// also includes the output of "locale -a" when it's available.
+func (c *systemLocale) installedLocales() map[string]bool {
+	out := map[string]bool{"C": true, "POSIX": true}
+
+	if entries, err := ioutil.ReadDir(c.localeDir); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				out[normalizeLocale(entry.Name())] = true
+			}
+		}
+	}
+
+	if c.listLocales {
+		data, err := exec.Command("locale", "-a").Output()
+		if err != nil {
+			grip.Debugf("problem listing locales with 'locale -a': %+v", err)
+		}
+
+		for _, name := range strings.Fields(string(data)) {
+			out[normalizeLocale(name)] = true
+		}
+	}
+
+	return out
+}
+
+func (c *systemLocale) defaultLocale() (string, string) {
+	for _, key := range []string{"LC_ALL", "LANG"} {
+		if value := os.Getenv(key); value != "" {
+			return value, key
+		}
+	}
+
+	for _, fn := range c.configFiles {
+		data, err := ioutil.ReadFile(fn)
+		if err != nil {
+			continue
+		}
+
+		doc, err := parseKeyValueConfig(data)
+		if err != nil {
+			grip.Warningf("problem parsing locale config '%s': %+v", fn, err)
+			continue
+		}
+
+		if value, ok := resolveConfigPath(doc, "LANG"); ok {
+			return configValueString(value), fn
+		}
+	}
+
+	return "C", "default"
+}
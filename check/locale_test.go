@@ -0,0 +1,80 @@
+package check
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("en_US.utf8", normalizeLocale("en_US.UTF-8"))
+	assert.Equal("en_US.utf8", normalizeLocale("en_US.utf8"))
+	assert.Equal("de_DE.iso885915@euro", normalizeLocale("de_DE.ISO-8859-15@euro"))
+	assert.Equal("C", normalizeLocale("C"))
+}
+
+func TestSystemLocaleCheck(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "greenbay-locale-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(os.MkdirAll(filepath.Join(dir, "locale", "en_US.utf8"), 0755))
+	conf := filepath.Join(dir, "locale.conf")
+	require.NoError(ioutil.WriteFile(conf, []byte("LANG=\"en_US.UTF-8\"\n"), 0644))
+
+	for _, key := range []string{"LC_ALL", "LANG"} {
+		if value, ok := os.LookupEnv(key); ok {
+			defer os.Setenv(key, value)
+			os.Unsetenv(key)
+		}
+	}
+
+	newCheck := func(available []string, def string) *systemLocale {
+		return &systemLocale{
+			Available:   available,
+			Default:     def,
+			Base:        NewBase("system-locale", 0),
+			localeDir:   filepath.Join(dir, "locale"),
+			configFiles: []string{filepath.Join(dir, "DOES-NOT-EXIST"), conf},
+		}
+	}
+
+	cases := []struct {
+		available []string
+		def       string
+		expected  bool
+	}{
+		{nil, "", false},
+		{[]string{"en_US.UTF-8", "C", "POSIX"}, "", true},
+		{[]string{"fr_FR.UTF-8"}, "", false},
+		{nil, "en_US.utf8", true},
+		{nil, "C", false},
+	}
+
+	for _, c := range cases {
+		check := newCheck(c.available, c.def)
+		check.Run(ctx)
+		output := check.Output()
+		assert.True(output.Completed)
+		assert.Equal(c.expected, output.Passed, "%v %s: %s", c.available, c.def, output.Error)
+		assert.Equal(!c.expected, check.Error() != nil)
+	}
+
+	// the environment takes precedence over config files
+	os.Setenv("LANG", "C.UTF-8")
+	defer os.Unsetenv("LANG")
+	check := newCheck(nil, "C.utf8")
+	check.Run(ctx)
+	assert.True(check.Output().Passed, check.Output().Error)
+}
@@ -0,0 +1,168 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "network-interface"
+	registry.AddJobType(name, func() amboy.Job {
+		return &networkInterface{
+			Base:    NewBase(name, 0), // (name, version)
+			sysRoot: "/sys/class/net",
+		}
+	})
+}
+
+// networkInterface asserts that a network interface exists and,
+// optionally, its link state, MTU, and the addresses and networks
+// assigned to it.
+type networkInterface struct {
+	Interface string   `bson:"interface" json:"interface" yaml:"interface"`
+	Up        *bool    `bson:"up" json:"up" yaml:"up"`
+	MTU       int      `bson:"mtu" json:"mtu" yaml:"mtu"`
+	Addresses []string `bson:"addresses" json:"addresses" yaml:"addresses"`
+	Networks  []string `bson:"networks" json:"networks" yaml:"networks"`
+	*Base     `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	sysRoot string
+}
+
+func (c *networkInterface) validate() error {
+	if c.Interface == "" {
+		return errors.Errorf("no interface specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	catcher := grip.NewCatcher()
+	for _, addr := range c.Addresses {
+		if net.ParseIP(addr) == nil {
+			catcher.Add(errors.Errorf("'%s' is not a valid IP address", addr))
+		}
+	}
+
+	for _, network := range c.Networks {
+		if _, _, err := net.ParseCIDR(network); err != nil {
+			catcher.Add(errors.Wrapf(err, "'%s' is not a valid network", network))
+		}
+	}
+
+	return catcher.Resolve()
+}
+
+func (c *networkInterface) Run(_ context.Context) {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	iface, err := net.InterfaceByName(c.Interface)
+	if err != nil {
+		c.setState(false)
+		c.AddError(errors.Wrapf(err, "interface '%s' does not exist", c.Interface))
+		return
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		c.setState(false)
+		c.AddError(errors.Wrapf(err, "problem reading addresses for '%s'", c.Interface))
+		return
+	}
+
+	var assigned []net.IP
+	var assignedStrs []string
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			assigned = append(assigned, ipNet.IP)
+			assignedStrs = append(assignedStrs, ipNet.String())
+		}
+	}
+
+	isUp := c.linkIsUp(iface)
+	catcher := grip.NewCatcher()
+
+	if c.Up != nil && *c.Up != isUp {
+		catcher.Add(errors.Errorf("interface '%s' up=%t, expected up=%t", c.Interface, isUp, *c.Up))
+	}
+
+	if c.MTU > 0 && iface.MTU != c.MTU {
+		catcher.Add(errors.Errorf("interface '%s' has mtu %d, expected %d", c.Interface, iface.MTU, c.MTU))
+	}
+
+	for _, addr := range c.Addresses {
+		expected := net.ParseIP(addr)
+		found := false
+		for _, ip := range assigned {
+			if ip.Equal(expected) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			catcher.Add(errors.Errorf("address '%s' is not assigned to '%s'", addr, c.Interface))
+		}
+	}
+
+	for _, network := range c.Networks {
+		_, ipNet, _ := net.ParseCIDR(network)
+		found := false
+		for _, ip := range assigned {
+			if ipNet.Contains(ip) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			catcher.Add(errors.Errorf("interface '%s' has no address in '%s'", c.Interface, network))
+		}
+	}
+
+	c.setMessage(fmt.Sprintf("interface '%s': up=%t, mtu=%d, flags=%s, addresses=[%s]",
+		c.Interface, isUp, iface.MTU, iface.Flags, strings.Join(assignedStrs, ", ")))
+
+	if catcher.HasErrors() {
+		c.setState(false)
+		c.AddError(catcher.Resolve())
+		return
+	}
+
+	c.setState(true)
+}
+
+// linkIsUp reports if the interface is administratively up and, when
+// sysfs is available, that the kernel reports an operational link.
+// Loopback and some virtual interfaces report an "unknown" operstate,
+// which we treat as up.
+func (c *networkInterface) linkIsUp(iface *net.Interface) bool {
+	if iface.Flags&net.FlagUp == 0 {
+		return false
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(c.sysRoot, iface.Name, "operstate"))
+	if os.IsNotExist(err) {
+		return true
+	} else if err != nil {
+		grip.Warningf("problem reading operstate for '%s': %+v", iface.Name, err)
+		return true
+	}
+
+	state := strings.TrimSpace(string(data))
+	return state == "up" || state == "unknown"
+}
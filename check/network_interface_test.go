@@ -0,0 +1,107 @@
+package check
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loopbackInterface(t *testing.T) net.Interface {
+	ifaces, err := net.Interfaces()
+	require.NoError(t, err)
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 && iface.Flags&net.FlagUp != 0 {
+			return iface
+		}
+	}
+
+	t.Skip("no loopback interface available")
+	return net.Interface{}
+}
+
+func TestNetworkInterfaceValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	check := &networkInterface{Base: NewBase("network-interface", 0)}
+	assert.Error(check.validate())
+
+	check.Interface = "eth0"
+	assert.NoError(check.validate())
+
+	check.Addresses = []string{"10.0.0.300"}
+	assert.Error(check.validate())
+
+	check.Addresses = []string{"10.0.0.3", "::1"}
+	check.Networks = []string{"10.0.0.0"}
+	assert.Error(check.validate())
+
+	check.Networks = []string{"10.0.0.0/8"}
+	assert.NoError(check.validate())
+}
+
+func TestNetworkInterfaceCheck(t *testing.T) {
+	assert := assert.New(t)
+	lo := loopbackInterface(t)
+	yes, no := true, false
+
+	cases := []struct {
+		check    *networkInterface
+		expected bool
+	}{
+		{&networkInterface{Interface: lo.Name}, true},
+		{&networkInterface{Interface: "greenbay-does-not-exist0"}, false},
+		{&networkInterface{Interface: lo.Name, Up: &yes, MTU: lo.MTU}, true},
+		{&networkInterface{Interface: lo.Name, Up: &no}, false},
+		{&networkInterface{Interface: lo.Name, MTU: lo.MTU + 1}, false},
+		{&networkInterface{Interface: lo.Name, Addresses: []string{"127.0.0.1"}}, true},
+		{&networkInterface{Interface: lo.Name, Addresses: []string{"192.0.2.1"}}, false},
+		{&networkInterface{Interface: lo.Name, Networks: []string{"127.0.0.0/8"}}, true},
+		{&networkInterface{Interface: lo.Name, Networks: []string{"192.0.2.0/24"}}, false},
+	}
+
+	for idx, c := range cases {
+		c.check.Base = NewBase("network-interface", 0)
+		c.check.sysRoot = "/sys/class/net"
+		c.check.Run(context.Background())
+		output := c.check.Output()
+
+		assert.True(output.Completed)
+		assert.Equal(c.expected, output.Passed, "%d: %s %s", idx, output.Message, output.Error)
+		if c.expected {
+			assert.NoError(c.check.Error())
+		} else {
+			assert.Error(c.check.Error())
+		}
+	}
+}
+
+func TestNetworkInterfaceOperstate(t *testing.T) {
+	assert := assert.New(t)
+	lo := loopbackInterface(t)
+
+	root, err := ioutil.TempDir("", "greenbay-sysfs-")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	require.NoError(t, os.Mkdir(filepath.Join(root, lo.Name), 0755))
+
+	check := &networkInterface{sysRoot: root}
+
+	// without sysfs data we fall back to interface flags
+	assert.True(check.linkIsUp(&lo))
+
+	for state, expected := range map[string]bool{"up": true, "unknown": true, "down": false, "dormant": false} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(root, lo.Name, "operstate"), []byte(state+"\n"), 0644))
+		assert.Equal(expected, check.linkIsUp(&lo), state)
+	}
+
+	down := lo
+	down.Flags &^= net.FlagUp
+	assert.False(check.linkIsUp(&down))
+}
@@ -0,0 +1,421 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// These are the ways that checks can match the output of programs and
+// commands, with the "match" field:
+//
+//   - "exact" (the default): the output equals the expected output,
+//     ignoring leading and trailing whitespace.
+//   - "regex": the expected output is a regular expression that
+//     matches some of the output, and "^" and "$" match at the
+//     beginning and end of lines.
+//   - "contains": the output contains the expected output.
+//   - "lines": the lines of the expected output are all in the
+//     output, in order, though the output may have other lines.
+//   - "json": the output and the expected output are equivalent JSON
+//     documents, regardless of formatting or the order of keys.
+//
+// The ignore_whitespace option collapses runs of whitespace to a single
+// space, and drops blank lines, before matching; the ignore_case option
+// makes matching case insensitive. Neither applies to JSON.
+const (
+	matchExact    = "exact"
+	matchRegex    = "regex"
+	matchContains = "contains"
+	matchLines    = "lines"
+	matchJSON     = "json"
+)
+
+type outputMatcher struct {
+	mode             string
+	ignoreWhitespace bool
+	ignoreCase       bool
+}
+
+func (m outputMatcher) getMode() string {
+	if m.mode == "" {
+		return matchExact
+	}
+
+	return m.mode
+}
+
+func (m outputMatcher) validate(expected string) error {
+	switch m.getMode() {
+	case matchExact, matchContains, matchLines:
+		return nil
+	case matchRegex:
+		_, err := m.regexp(expected)
+		return err
+	case matchJSON:
+		if m.ignoreCase || m.ignoreWhitespace {
+			return errors.New("json output matching does not support ignore_case or ignore_whitespace")
+		}
+
+		var doc interface{}
+		return errors.Wrap(json.Unmarshal([]byte(expected), &doc), "expected output is not valid json")
+	default:
+		return errors.Errorf("'%s' is not a supported output match", m.mode)
+	}
+}
+
+func (m outputMatcher) regexp(expected string) (*regexp.Regexp, error) {
+	expr := "(?m)" + strings.Trim(expected, "\r\t\n ")
+	if m.ignoreCase {
+		expr = "(?i)" + expr
+	}
+
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "expected output '%s' is not a valid regular expression", expected)
+	}
+
+	return pattern, nil
+}
+
+// normalize trims the text, and applies the whitespace and case options.
+func (m outputMatcher) normalize(text string) string {
+	text = strings.Trim(strings.Replace(text, "\r\n", "\n", -1), "\r\t\n ")
+
+	if m.ignoreCase {
+		text = strings.ToLower(text)
+	}
+
+	if m.ignoreWhitespace {
+		var lines []string
+		for _, line := range strings.Split(text, "\n") {
+			if fields := strings.Fields(line); len(fields) > 0 {
+				lines = append(lines, strings.Join(fields, " "))
+			}
+		}
+		text = strings.Join(lines, "\n")
+	}
+
+	return text
+}
+
+// match returns an error when the output doesn't match the expected
+// output, along with a message that describes the difference, as a
+// unified diff where that makes sense.
+func (m outputMatcher) match(expected, actual string) (string, error) {
+	if err := m.validate(expected); err != nil {
+		return "", err
+	}
+
+	trimmedExpected := m.withoutOptions().normalize(expected)
+	trimmedActual := m.withoutOptions().normalize(actual)
+
+	switch m.getMode() {
+	case matchRegex:
+		// the pattern handles the case option, so only the
+		// whitespace option applies to the output.
+		pattern, _ := m.regexp(expected)
+		if pattern.MatchString(outputMatcher{ignoreWhitespace: m.ignoreWhitespace}.normalize(actual)) {
+			return "", nil
+		}
+
+		return trimmedActual, errors.Errorf("output does not match regular expression '%s'", pattern)
+	case matchContains:
+		if strings.Contains(m.normalize(actual), m.normalize(expected)) {
+			return "", nil
+		}
+
+		return trimmedActual, errors.New("output does not contain the expected output")
+	case matchLines:
+		if line, ok := m.matchLines(expected, actual); !ok {
+			return unifiedDiff("expected", "actual", trimmedExpected, trimmedActual),
+				errors.Errorf("expected line '%s' is not in the output, in order", line)
+		}
+
+		return "", nil
+	case matchJSON:
+		return m.matchJSON(expected, actual)
+	default:
+		if m.normalize(expected) == m.normalize(actual) {
+			return "", nil
+		}
+
+		return unifiedDiff("expected", "actual", trimmedExpected, trimmedActual),
+			errors.New("expected output does not match actual output")
+	}
+}
+
+// replaceable reports whether the actual output can replace the
+// expected output, which only makes sense for modes that match the
+// whole output.
+func (m outputMatcher) replaceable() bool {
+	mode := m.getMode()
+	return mode == matchExact || mode == matchJSON
+}
+
+func (m outputMatcher) withoutOptions() outputMatcher {
+	return outputMatcher{mode: m.mode}
+}
+
+// matchLines reports the first line of the expected output that is not
+// in the output, after the previous lines.
+func (m outputMatcher) matchLines(expected, actual string) (string, bool) {
+	lines := strings.Split(m.normalize(actual), "\n")
+
+	idx := 0
+	for _, line := range strings.Split(m.normalize(expected), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		for idx < len(lines) && lines[idx] != line {
+			idx++
+		}
+
+		if idx == len(lines) {
+			return line, false
+		}
+		idx++
+	}
+
+	return "", true
+}
+
+func (m outputMatcher) matchJSON(expected, actual string) (string, error) {
+	var expectedDoc, actualDoc interface{}
+
+	if err := json.Unmarshal([]byte(expected), &expectedDoc); err != nil {
+		return "", errors.Wrap(err, "expected output is not valid json")
+	}
+
+	if err := json.Unmarshal([]byte(actual), &actualDoc); err != nil {
+		return strings.Trim(actual, "\r\t\n "), errors.Wrap(err, "output is not valid json")
+	}
+
+	if reflect.DeepEqual(expectedDoc, actualDoc) {
+		return "", nil
+	}
+
+	// marshaling sorts the keys of objects, so the diff only has
+	// the differences in the values.
+	expectedOut, _ := json.MarshalIndent(expectedDoc, "", "  ")
+	actualOut, _ := json.MarshalIndent(actualDoc, "", "  ")
+
+	return unifiedDiff("expected", "actual", string(expectedOut), string(actualOut)),
+		errors.New("output is not equivalent to the expected json")
+}
+
+// goldenOutput records the actual output of checks that failed because
+// their output didn't match, and implements the
+// greenbay.GoldenOutputChecker interface for them, so that "greenbay
+// run --update-golden" can replace their expected output.
+type goldenOutput struct {
+	goldenMutex  sync.RWMutex
+	actualOutput string
+	hasOutput    bool
+	outputFile   string
+}
+
+func (g *goldenOutput) setExpectedOutputFile(path string) {
+	g.goldenMutex.Lock()
+	defer g.goldenMutex.Unlock()
+
+	g.outputFile = path
+}
+
+// ExpectedOutputFile returns the path of the file that the check read
+// its expected output from, if any.
+func (g *goldenOutput) ExpectedOutputFile() string {
+	g.goldenMutex.RLock()
+	defer g.goldenMutex.RUnlock()
+
+	return g.outputFile
+}
+
+func (g *goldenOutput) setActualOutput(m outputMatcher, output string) {
+	if !m.replaceable() {
+		return
+	}
+
+	g.goldenMutex.Lock()
+	defer g.goldenMutex.Unlock()
+
+	// the output isn't trimmed, so that the expected output keeps
+	// its indentation and trailing newlines, which doesn't affect
+	// matching, since exact matches ignore them.
+	g.actualOutput = strings.Replace(output, "\r\n", "\n", -1)
+	g.hasOutput = true
+}
+
+// ActualOutput returns the output of the check, and true if the check
+// failed only because its output didn't match the expected output.
+func (g *goldenOutput) ActualOutput() (string, bool) {
+	g.goldenMutex.RLock()
+	defer g.goldenMutex.RUnlock()
+
+	return g.actualOutput, g.hasOutput
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Unified Diffs
+
+const (
+	diffContext = 3
+
+	// diffs compare every line of each text, so larger texts are
+	// shown as completely different, rather than using too much memory.
+	maxDiffCells = 1 << 22
+)
+
+type diffLine struct {
+	op   byte
+	text string
+}
+
+func splitDiffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(text, "\n")
+}
+
+// diffLines returns the shortest edit script that turns the first
+// lines into the second, using their longest common subsequence.
+func diffLines(from, to []string) []diffLine {
+	out := make([]diffLine, 0, len(from)+len(to))
+
+	if len(from)*len(to) > maxDiffCells {
+		for _, line := range from {
+			out = append(out, diffLine{'-', line})
+		}
+		for _, line := range to {
+			out = append(out, diffLine{'+', line})
+		}
+		return out
+	}
+
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			out = append(out, diffLine{' ', from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, diffLine{'-', from[i]})
+			i++
+		default:
+			out = append(out, diffLine{'+', to[j]})
+			j++
+		}
+	}
+
+	for ; i < len(from); i++ {
+		out = append(out, diffLine{'-', from[i]})
+	}
+	for ; j < len(to); j++ {
+		out = append(out, diffLine{'+', to[j]})
+	}
+
+	return out
+}
+
+// unifiedDiff returns the differences between two texts in the format
+// of "diff -u", or an empty string when they're the same.
+func unifiedDiff(fromName, toName, from, to string) string {
+	lines := diffLines(splitDiffLines(from), splitDiffLines(to))
+
+	// the line numbers, in each text, before each line of the diff.
+	fromLine := make([]int, len(lines)+1)
+	toLine := make([]int, len(lines)+1)
+	for idx, line := range lines {
+		fromLine[idx+1] = fromLine[idx]
+		toLine[idx+1] = toLine[idx]
+		if line.op != '+' {
+			fromLine[idx+1]++
+		}
+		if line.op != '-' {
+			toLine[idx+1]++
+		}
+	}
+
+	hunkRange := func(start, length int) string {
+		if length == 0 {
+			return fmt.Sprintf("%d,0", start)
+		}
+		if length == 1 {
+			return fmt.Sprintf("%d", start+1)
+		}
+		return fmt.Sprintf("%d,%d", start+1, length)
+	}
+
+	var out []string
+	idx := 0
+	for idx < len(lines) {
+		for idx < len(lines) && lines[idx].op == ' ' {
+			idx++
+		}
+		if idx == len(lines) {
+			break
+		}
+
+		// a hunk includes the changes that are close enough that
+		// their context would overlap.
+		last := idx
+		for next := idx; next < len(lines); next++ {
+			if lines[next].op != ' ' {
+				last = next
+			} else if next-last > 2*diffContext {
+				break
+			}
+		}
+
+		start := idx - diffContext
+		if start < 0 {
+			start = 0
+		}
+		stop := last + diffContext + 1
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+
+		if len(out) == 0 {
+			out = append(out, "--- "+fromName, "+++ "+toName)
+		}
+
+		out = append(out, fmt.Sprintf("@@ -%s +%s @@",
+			hunkRange(fromLine[start], fromLine[stop]-fromLine[start]),
+			hunkRange(toLine[start], toLine[stop]-toLine[start])))
+		for _, line := range lines[start:stop] {
+			out = append(out, string(line.op)+line.text)
+		}
+
+		idx = stop
+	}
+
+	return strings.Join(out, "\n")
+}
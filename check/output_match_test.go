@@ -0,0 +1,181 @@
+package check
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputMatcherModes(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		matcher  outputMatcher
+		expected string
+		actual   string
+		matches  bool
+	}{
+		{outputMatcher{}, "hello world", "hello world\n", true},
+		{outputMatcher{}, "hello world", "hello  world", false},
+		{outputMatcher{mode: matchExact}, "hello world", "Hello World", false},
+		{outputMatcher{ignoreCase: true}, "hello world", "Hello World", true},
+		{outputMatcher{ignoreWhitespace: true}, "hello world\nbye", "  hello \t world\n\n bye ", true},
+		{outputMatcher{ignoreWhitespace: true}, "hello world", "helloworld", false},
+
+		{outputMatcher{mode: matchRegex}, `^version \d+\.\d+$`, "greenbay\nversion 1.2\n", true},
+		{outputMatcher{mode: matchRegex}, `^version \d+\.\d+$`, "version one", false},
+		{outputMatcher{mode: matchRegex, ignoreCase: true}, `^VERSION \d+$`, "version 1", true},
+		{outputMatcher{mode: matchRegex, ignoreWhitespace: true}, `^a b$`, "  a   b  ", true},
+
+		{outputMatcher{mode: matchContains}, "world", "hello world", true},
+		{outputMatcher{mode: matchContains}, "World", "hello world", false},
+		{outputMatcher{mode: matchContains, ignoreCase: true}, "World", "hello world", true},
+
+		{outputMatcher{mode: matchLines}, "one\nthree", "one\ntwo\nthree\nfour", true},
+		{outputMatcher{mode: matchLines}, "three\none", "one\ntwo\nthree\nfour", false},
+		{outputMatcher{mode: matchLines}, "on", "one\ntwo", false},
+		{outputMatcher{mode: matchLines, ignoreWhitespace: true}, "one  two\n\nfour", "one two\nthree\n  four", true},
+
+		{outputMatcher{mode: matchJSON}, `{"a": 1, "b": [true, null]}`, `{"b":[true,null],"a":1.0}`, true},
+		{outputMatcher{mode: matchJSON}, `{"a": 1}`, `{"a": 2}`, false},
+		{outputMatcher{mode: matchJSON}, `{"a": 1}`, `not json`, false},
+	}
+
+	for _, test := range cases {
+		_, err := test.matcher.match(test.expected, test.actual)
+		if test.matches {
+			assert.NoError(err, "%+v: %s", test.matcher, test.expected)
+		} else {
+			assert.Error(err, "%+v: %s", test.matcher, test.expected)
+		}
+	}
+}
+
+func TestOutputMatcherValidation(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(outputMatcher{}.validate("anything"))
+	assert.NoError(outputMatcher{mode: matchRegex}.validate("^a+$"))
+	assert.Error(outputMatcher{mode: matchRegex}.validate("(a+"))
+	assert.NoError(outputMatcher{mode: matchJSON}.validate(`[1, 2]`))
+	assert.Error(outputMatcher{mode: matchJSON}.validate(`[1, 2`))
+	assert.Error(outputMatcher{mode: matchJSON, ignoreCase: true}.validate(`[1, 2]`))
+	assert.Error(outputMatcher{mode: "glob"}.validate("*"))
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("", unifiedDiff("a", "b", "one\ntwo", "one\ntwo"))
+
+	assert.Equal(strings.Join([]string{
+		"--- expected",
+		"+++ actual",
+		"@@ -1,3 +1,3 @@",
+		" one",
+		"-two",
+		"+TWO",
+		" three",
+	}, "\n"), unifiedDiff("expected", "actual", "one\ntwo\nthree", "one\nTWO\nthree"))
+
+	// changes that are far apart are in separate hunks, with context.
+	from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12"
+	to := "1\nx\n3\n4\n5\n6\n7\n8\n9\n10\n11"
+	assert.Equal(strings.Join([]string{
+		"--- expected",
+		"+++ actual",
+		"@@ -1,5 +1,5 @@",
+		" 1",
+		"-2",
+		"+x",
+		" 3",
+		" 4",
+		" 5",
+		"@@ -9,4 +9,3 @@",
+		" 9",
+		" 10",
+		" 11",
+		"-12",
+	}, "\n"), unifiedDiff("expected", "actual", from, to))
+
+	assert.Equal(strings.Join([]string{
+		"--- expected",
+		"+++ actual",
+		"@@ -0,0 +1 @@",
+		"+new",
+	}, "\n"), unifiedDiff("expected", "actual", "", "new"))
+
+	// the exact match mode reports the diff.
+	msg, err := outputMatcher{}.match("one\ntwo\nthree", "one\nTWO\nthree\n")
+	assert.Error(err)
+	assert.Contains(msg, "-two\n+TWO")
+}
+
+func TestShellOperationMatchesOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires sh")
+	}
+
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	run := func(check *shellOperation) *shellOperation {
+		check.Base = NewBase("shell-operation", 0)
+		check.Run(ctx)
+		return check
+	}
+
+	assert.True(run(&shellOperation{Command: "echo hello world", ExpectedOutput: "hello world"}).getState())
+	assert.False(run(&shellOperation{Command: "echo hello world", ExpectedOutput: "hello"}).getState())
+	assert.True(run(&shellOperation{Command: "echo hello world", ExpectedOutput: "hello", Match: matchContains}).getState())
+	assert.True(run(&shellOperation{Command: `echo '{"b": 2, "a": 1}'`, ExpectedOutput: `{"a":1,"b":2}`, Match: matchJSON}).getState())
+	assert.True(run(&shellOperation{Command: "echo HELLO", ExpectedOutput: "hello", IgnoreCase: true}).getState())
+
+	check := run(&shellOperation{Command: "echo hello", ExpectedOutput: "(hello", Match: matchRegex})
+	assert.False(check.getState())
+	assert.Error(check.Error())
+
+	// the output of failed commands is only matched when the
+	// command should fail.
+	assert.False(run(&shellOperation{Command: "echo hello; false", ExpectedOutput: "hello"}).getState())
+	assert.True(run(&shellOperation{Command: "echo hello; false", ExpectedOutput: "hello", shouldFail: true}).getState())
+	assert.False(run(&shellOperation{Command: "echo bye; false", ExpectedOutput: "hello", shouldFail: true}).getState())
+}
+
+func TestChecksRecordActualOutputWhenOutputDoesNotMatch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires sh")
+	}
+
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	run := func(check *shellOperation) *shellOperation {
+		check.Base = NewBase("shell-operation", 0)
+		check.Run(ctx)
+		return check
+	}
+
+	// the check passed
+	_, ok := run(&shellOperation{Command: "echo hello", ExpectedOutput: "hello"}).ActualOutput()
+	assert.False(ok)
+
+	// the command failed
+	_, ok = run(&shellOperation{Command: "echo hello; false", ExpectedOutput: "bye"}).ActualOutput()
+	assert.False(ok)
+
+	// patterns can't be replaced by the output
+	_, ok = run(&shellOperation{Command: "echo hello", ExpectedOutput: "bye", Match: matchContains}).ActualOutput()
+	assert.False(ok)
+
+	output, ok := run(&shellOperation{Command: "echo hello; echo world", ExpectedOutput: "bye"}).ActualOutput()
+	assert.True(ok)
+	assert.Equal("hello\nworld\n", output)
+
+	output, ok = run(&shellOperation{Command: `echo '{"a": 2}'`, ExpectedOutput: `{"a": 1}`, Match: matchJSON}).ActualOutput()
+	assert.True(ok)
+	assert.Equal("{\"a\": 2}\n", output)
+}
@@ -58,3 +58,76 @@ func TestPackageCheckImplementation(t *testing.T) {
 	assert.Error(check.Error())
 	assert.False(check.Output().Passed)
 }
+
+func TestPackageCheckVersionConstraints(t *testing.T) {
+	assert := assert.New(t)
+	passer := packageCheckerFactory([]string{"echo", "foo"})
+	failer := packageCheckerFactory([]string{"python", "-c", "exit(1)"})
+	versioner := packageVersioner{
+		scheme:  "rpm",
+		version: func(string) (string, error) { return "1.1.1k-5.el8", nil },
+	}
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		installed bool
+		checker   packageChecker
+		version   string
+		passes    bool
+	}{
+		{true, passer, ">=1.1.1", true},
+		{true, passer, "1.1.1k-5.el8", true},
+		{true, passer, ">=1.1.1l", false},
+		{true, passer, "<1.1", false},
+		{true, failer, ">=1.1.1", false},
+		{false, passer, "<1.1", true},
+		{false, passer, ">=1.1", false},
+		{false, failer, ">=1.1", true},
+	} {
+		check := &packageInstalled{
+			Package:   "openssl",
+			Version:   test.version,
+			checker:   test.checker,
+			versioner: versioner,
+			Base:      NewBase("test", 0),
+			installed: test.installed,
+		}
+		check.Run(ctx)
+		assert.Equal(test.passes, check.Output().Passed, "installed=%t %s", test.installed, test.version)
+		assert.Equal(!test.passes, check.Error() != nil)
+		assert.Equal("rpm", check.Scheme)
+	}
+
+	check := &packageInstalled{
+		Package:   "openssl",
+		Version:   ">=1.1.1",
+		checker:   passer,
+		versioner: versioner,
+		Base:      NewBase("test", 0),
+		installed: true,
+	}
+	check.Run(ctx)
+	assert.Contains(check.Output().Message, "1.1.1k-5.el8", "reports the installed version")
+
+	// invalid constraints and unsupported checks are errors
+	check = &packageInstalled{
+		Package:   "openssl",
+		Version:   ">=",
+		checker:   passer,
+		versioner: versioner,
+		Base:      NewBase("test", 0),
+		installed: true,
+	}
+	check.Run(ctx)
+	assert.Error(check.Error())
+
+	check = &packageInstalled{
+		Package:   "openssl",
+		Version:   ">=1",
+		checker:   passer,
+		Base:      NewBase("test", 0),
+		installed: true,
+	}
+	check.Run(ctx)
+	assert.Error(check.Error())
+}
@@ -3,6 +3,7 @@ package check
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/registry"
@@ -12,10 +13,12 @@ import (
 // this would be an init function but is simply called from the init()
 // in init.go to avoid ordering effects.
 func registerPackageChecks() {
-	packageCheckerFactoryFactory := func(name string, installed bool, checker packageChecker) func() amboy.Job {
+	packageCheckerFactoryFactory := func(name, manager string, installed bool, checker packageChecker, versioner packageVersioner) func() amboy.Job {
 		return func() amboy.Job {
 			return &packageInstalled{
+				manager:   manager,
 				checker:   checker,
+				versioner: versioner,
 				Base:      NewBase(name, 0),
 				installed: installed,
 			}
@@ -26,32 +29,163 @@ func registerPackageChecks() {
 
 	for pkg, checker := range packageCheckerRegistry {
 		name = fmt.Sprintf("%s-installed", pkg)
-		registry.AddJobType(name, packageCheckerFactoryFactory(name, true, checker))
+		registry.AddJobType(name, packageCheckerFactoryFactory(name, pkg, true, checker, packageVersionerRegistry[pkg]))
 
 		name = fmt.Sprintf("%s-not-installed", pkg)
-		registry.AddJobType(name, packageCheckerFactoryFactory(name, false, checker))
+		registry.AddJobType(name, packageCheckerFactoryFactory(name, pkg, false, checker, packageVersionerRegistry[pkg]))
 	}
+
+	// the package manager for these checks is detected when they
+	// run, from the system's os-release file.
+	systemPackageFactoryFactory := func(name string, installed bool) func() amboy.Job {
+		return func() amboy.Job {
+			return &packageInstalled{
+				Base:      NewBase(name, 0),
+				installed: installed,
+				osRelease: defaultOSRelease,
+			}
+		}
+	}
+
+	registry.AddJobType("package-installed", systemPackageFactoryFactory("package-installed", true))
+	registry.AddJobType("package-not-installed", systemPackageFactoryFactory("package-not-installed", false))
 }
 
+// packageInstalled asserts that a package is (or is not) installed.
+// With a version constraint, the installed version must also satisfy
+// the constraint, using the package manager's version ordering
+// unless another scheme is specified. For "not-installed" checks,
+// only installed versions that satisfy the constraint are errors.
+//
+// The "package-installed" and "package-not-installed" checks detect
+// the package manager from /etc/os-release, and can use different
+// package names on each distribution: names are keyed by the ID or
+// ID_LIKE values (e.g. "debian" or "rhel") or by package manager,
+// and the package field is the default.
+//
+// For pip and conda, the environment selects a python interpreter,
+// virtualenv, or conda environment (by name or path) to check, rather
+// than the default.
 type packageInstalled struct {
-	Package string `bson:"package" json:"package" yaml:"package"`
-	*Base   `bson:"metadata" json:"metadata" yaml:"metadata"`
+	Package     string            `bson:"package" json:"package" yaml:"package"`
+	Names       map[string]string `bson:"names" json:"names" yaml:"names"`
+	Version     string            `bson:"version" json:"version" yaml:"version"`
+	Scheme      string            `bson:"scheme" json:"scheme" yaml:"scheme"`
+	Environment string            `bson:"environment" json:"environment" yaml:"environment"`
+	*Base       `bson:"metadata" json:"metadata" yaml:"metadata"`
 
 	installed bool
+	manager   string
 	checker   packageChecker
+	versioner packageVersioner
+	osRelease string
+}
+
+// detectPackageManager sets up checks that use the system's package
+// manager, and resolves the package name for the distribution.
+func (c *packageInstalled) detectPackageManager() error {
+	if c.checker != nil {
+		return nil
+	}
+
+	manager, ids, err := detectSystemPackageManager(c.osRelease)
+	if err != nil {
+		return err
+	}
+
+	c.manager = manager
+	c.checker = packageCheckerRegistry[manager]
+	c.versioner = packageVersionerRegistry[manager]
+
+	key, ok := systemPackageNameKey(ids, manager, func(k string) bool { _, ok := c.Names[k]; return ok })
+	if ok {
+		c.Package = c.Names[key]
+	}
+
+	if c.Package == "" {
+		return errors.Errorf("no package name for %s on [%s] in '%s' (%s) check",
+			manager, strings.Join(ids, ", "), c.ID(), c.Name())
+	}
+
+	return nil
+}
+
+func (c *packageInstalled) constraints() (*versionConstraints, error) {
+	if c.Version == "" {
+		return nil, nil
+	}
+
+	if c.versioner.version == nil {
+		return nil, errors.Errorf("'%s' checks do not support version constraints", c.Name())
+	}
+
+	if c.Scheme == "" {
+		c.Scheme = c.versioner.scheme
+	}
+
+	return parseVersionConstraints(c.Scheme, c.Version)
 }
 
 func (c *packageInstalled) Run(_ context.Context) {
 	c.startTask()
 	defer c.MarkComplete()
 
+	if err := c.detectPackageManager(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	if c.Environment != "" {
+		var err error
+		c.checker, c.versioner, err = environmentPackageChecker(c.manager, c.Environment)
+		if err != nil {
+			c.setState(false)
+			c.AddError(err)
+			return
+		}
+	}
+
+	constraints, err := c.constraints()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
 	exists, msg := c.checker(c.Package)
 
+	var version string
+	if exists && constraints != nil {
+		version, err = c.versioner.version(c.Package)
+		if err != nil {
+			c.setState(false)
+			c.setMessage(msg)
+			c.AddError(err)
+			return
+		}
+
+		satisfies, err := constraints.check(version)
+		if err != nil {
+			c.setState(false)
+			c.AddError(errors.Wrapf(err, "problem comparing version of package '%s'", c.Package))
+			return
+		}
+
+		exists = satisfies
+		msg = fmt.Sprintf("package '%s' version '%s' (%s) satisfies '%s': %t",
+			c.Package, version, c.Scheme, c.Version, satisfies)
+		c.setMessage(msg)
+	}
+
 	if !c.installed {
 		// this is the check for "package isn't installed" tasks
 		c.setState(!exists)
 
-		if exists {
+		if exists && version != "" {
+			c.AddError(errors.Errorf("package '%s' version '%s' satisfies '%s' (check=%s) and should not",
+				c.Package, version, c.Version, c.Name()))
+		} else if exists {
 			c.setMessage(msg)
 			c.AddError(errors.Errorf("package '%s' exists (check=%s) and should not",
 				c.Package, c.Name()))
@@ -62,7 +196,10 @@ func (c *packageInstalled) Run(_ context.Context) {
 	// check for package is installed.
 	c.setState(exists)
 
-	if !exists {
+	if !exists && version != "" {
+		c.AddError(errors.Errorf("package '%s' version '%s' does not satisfy '%s'",
+			c.Package, version, c.Version))
+	} else if !exists {
 		c.setMessage(msg)
 		c.AddError(errors.Errorf("package %s does note exist and should", c.Package))
 	}
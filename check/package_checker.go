@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
 )
 
 type packageChecker func(string) (bool, string)
@@ -28,3 +31,137 @@ func packageCheckerFactory(args []string) packageChecker {
 		return true, output
 	}
 }
+
+// packageVersioner determines the installed version of a package,
+// and names the version scheme that the package manager uses to
+// order versions.
+type packageVersioner struct {
+	scheme  string
+	version func(string) (string, error)
+}
+
+// this is populated in init.go's init(), alongside the
+// packageCheckerRegistry.
+var packageVersionerRegistry map[string]packageVersioner
+
+// packageVersionerFactory runs the command, with the package name
+// appended, and uses the parser to extract the installed versions
+// from its output. If there are several installed versions, the
+// newest is reported.
+func packageVersionerFactory(scheme string, args []string, parser func(string, string) []string) packageVersioner {
+	return packageVersioner{
+		scheme: scheme,
+		version: func(name string) (string, error) {
+			localArgs := append(append([]string{}, args...), name)
+
+			out, err := exec.Command(localArgs[0], localArgs[1:]...).CombinedOutput()
+			output := strings.Trim(string(out), "\r\t\n ")
+			if err != nil {
+				return "", errors.Wrapf(err, "problem running '%s': %s",
+					strings.Join(localArgs, " "), output)
+			}
+
+			return newestVersion(scheme, parser(name, output), name)
+		},
+	}
+}
+
+func newestVersion(scheme string, versions []string, name string) (string, error) {
+	compare := versionSchemes[scheme]
+
+	var newest string
+	for _, v := range versions {
+		if _, err := compare(v, v); err != nil {
+			grip.Debugf("skipping invalid %s version '%s' for package '%s'", scheme, v, name)
+			continue
+		}
+
+		if newest == "" {
+			newest = v
+		} else if cmp, _ := compare(v, newest); cmp > 0 {
+			newest = v
+		}
+	}
+
+	if newest == "" {
+		return "", errors.Errorf("could not determine installed version of package '%s'", name)
+	}
+
+	return newest, nil
+}
+
+// parseDpkgQueryVersions parses the output of dpkg-query with a
+// "${Status}\t${Version}\n" format, ignoring packages that are not
+// fully installed (e.g. removed packages with config files left).
+func parseDpkgQueryVersions(_, output string) []string {
+	var out []string
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) == 2 && strings.HasSuffix(parts[0], " installed") {
+			out = append(out, strings.TrimSpace(parts[1]))
+		}
+	}
+
+	return out
+}
+
+// parseRPMQueryVersions parses the output of rpm -q with a
+// "%{EPOCH}:%{VERSION}-%{RELEASE}\n" format.
+func parseRPMQueryVersions(_, output string) []string {
+	var out []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimPrefix(strings.TrimSpace(line), "(none):")
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+
+	return out
+}
+
+// parseNameVersionsLine parses output with lines of the form
+// "<name> <version> [<version> ...]", as from "pacman -Q" or "brew
+// list --versions".
+func parseNameVersionsLine(name, output string) []string {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 1 && fields[0] == name {
+			return fields[1:]
+		}
+	}
+
+	return nil
+}
+
+// parsePipShowVersion parses the "Version:" field of "pip show".
+func parsePipShowVersion(_, output string) []string {
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "Version:") {
+			return []string{strings.TrimSpace(strings.TrimPrefix(line, "Version:"))}
+		}
+	}
+
+	return nil
+}
+
+// parseGemListVersions parses lines of the form
+// "<name> (default: 1.2.3, 1.0.0 x86_64-linux)" from "gem list".
+func parseGemListVersions(name, output string) []string {
+	var out []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, name+" (") || !strings.HasSuffix(line, ")") {
+			continue
+		}
+
+		list := strings.TrimSuffix(strings.TrimPrefix(line, name+" ("), ")")
+		for _, v := range strings.Split(list, ",") {
+			fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(v), "default:"))
+			if len(fields) > 0 {
+				out = append(out, fields[0])
+			}
+		}
+	}
+
+	return out
+}
@@ -23,3 +23,51 @@ func TestPackageCheckerFactory(t *testing.T) {
 		assert.NotEqual("", message)
 	}
 }
+
+func TestPackageVersionParsers(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"1.1.1f-1ubuntu2"}, parseDpkgQueryVersions("openssl",
+		"install ok installed\t1.1.1f-1ubuntu2\n"))
+	assert.Len(parseDpkgQueryVersions("openssl", "deinstall ok config-files\t1.1.1f-1ubuntu2"), 0)
+
+	assert.Equal([]string{"7.29.0-51.el7", "1:1.0.2k-19.el7"}, parseRPMQueryVersions("pkg",
+		"(none):7.29.0-51.el7\n1:1.0.2k-19.el7\n"))
+
+	assert.Equal([]string{"1:2.3-1"}, parseNameVersionsLine("foo", "foo 1:2.3-1"))
+	assert.Equal([]string{"1.1.1w", "3.0.2_1"}, parseNameVersionsLine("openssl", "openssl 1.1.1w 3.0.2_1\n"))
+	assert.Len(parseNameVersionsLine("foo", "foobar 1.0"), 0)
+
+	assert.Equal([]string{"2.31.0"}, parsePipShowVersion("requests",
+		"Name: requests\nVersion: 2.31.0\nSummary: HTTP"))
+	assert.Len(parsePipShowVersion("requests", "WARNING: Package(s) not found"), 0)
+
+	assert.Equal([]string{"2.4.10", "2.3.0"}, parseGemListVersions("bundler",
+		"\n*** LOCAL GEMS ***\n\nbundler (default: 2.4.10, 2.3.0)\n"))
+	assert.Equal([]string{"1.13.8"}, parseGemListVersions("nokogiri", "nokogiri (1.13.8 x86_64-linux)"))
+	assert.Len(parseGemListVersions("rake", "rake-compiler (1.2.0)"), 0)
+}
+
+func TestPackageVersionerFactory(t *testing.T) {
+	assert := assert.New(t)
+
+	versioner := packageVersionerFactory("rpm", []string{"echo", "openssl 1.0.2k 1.1.1w 1.1.1"}, parseNameVersionsLine)
+	assert.Equal("rpm", versioner.scheme)
+	version, err := versioner.version("foo")
+	assert.Error(err, "no line for package")
+	assert.Equal("", version)
+
+	versioner = packageVersionerFactory("rpm", []string{"echo", "openssl 1.0.2k 1.1.1w 1.1.1"}, func(_, out string) []string {
+		return parseNameVersionsLine("openssl", out)
+	})
+	version, err = versioner.version("openssl")
+	assert.NoError(err)
+	assert.Equal("1.1.1w", version, "should report the newest version")
+
+	versioner = packageVersionerFactory("pep440", []string{"exit"}, parsePipShowVersion)
+	_, err = versioner.version("foo")
+	assert.Error(err)
+
+	_, err = newestVersion("semver", []string{"2.7", "foo"}, "python")
+	assert.Error(err, "no valid versions")
+}
@@ -0,0 +1,456 @@
+package check
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// packageRecord is an entry for one package in a package manager's
+// database.
+type packageRecord struct {
+	version   string
+	state     string
+	installed bool
+}
+
+// packageDatabase reads a package manager's database of installed
+// packages directly, rather than running the package manager once
+// per package. The database is read on first use and cached, and is
+// only read again if the modification time of one of its paths
+// changes.
+type packageDatabase struct {
+	name      string
+	paths     func() ([]string, error)
+	read      func([]string) (map[string][]packageRecord, error)
+	normalize func(string) string
+
+	mu       sync.Mutex
+	stamps   map[string]time.Time
+	packages map[string][]packageRecord
+}
+
+var (
+	dpkgDatabase   = newDpkgDatabase("/var/lib/dpkg/status")
+	pacmanDatabase = newPacmanDatabase("/var/lib/pacman/local")
+	pipDatabase    = newPipDatabase(pythonSitePackages(pipInterpreter))
+)
+
+func (db *packageDatabase) lookup(name string) ([]packageRecord, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	paths, err := db.paths()
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s database is not available", db.name)
+	}
+
+	stamps := map[string]time.Time{}
+	for _, path := range paths {
+		stat, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		stamps[path] = stat.ModTime()
+	}
+
+	if len(stamps) == 0 {
+		return nil, errors.Errorf("%s database is not available", db.name)
+	}
+
+	if db.packages == nil || !sameModTimes(db.stamps, stamps) {
+		packages, err := db.read(paths)
+		if err != nil {
+			return nil, errors.Wrapf(err, "problem reading %s database", db.name)
+		}
+
+		grip.Debugf("read %d packages from %s database", len(packages), db.name)
+		db.packages = packages
+		db.stamps = stamps
+	}
+
+	if db.normalize != nil {
+		name = db.normalize(name)
+	}
+
+	return db.packages[name], nil
+}
+
+func sameModTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for path, mtime := range a {
+		if other, ok := b[path]; !ok || !other.Equal(mtime) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nativePackageChecker answers from the package database, and uses
+// the fallback, which runs the package manager, when the database
+// isn't available.
+func nativePackageChecker(db *packageDatabase, fallback packageChecker) packageChecker {
+	return func(name string) (bool, string) {
+		records, err := db.lookup(name)
+		if err != nil {
+			grip.Debugf("falling back to running %s: %+v", db.name, err)
+			return fallback(name)
+		}
+
+		if len(records) == 0 {
+			return false, fmt.Sprintf("%s package '%s' is not installed", db.name, name)
+		}
+
+		var states []string
+		for _, r := range records {
+			if r.installed {
+				return true, fmt.Sprintf("%s package '%s' version '%s' is installed", db.name, name, r.version)
+			}
+			states = append(states, r.state)
+		}
+
+		return false, fmt.Sprintf("%s package '%s' is not installed (state: %s)",
+			db.name, name, strings.Join(states, ", "))
+	}
+}
+
+// nativePackageVersioner reports the newest installed version from
+// the package database, and uses the fallback when the database
+// isn't available.
+func nativePackageVersioner(db *packageDatabase, fallback packageVersioner) packageVersioner {
+	return packageVersioner{
+		scheme: fallback.scheme,
+		version: func(name string) (string, error) {
+			records, err := db.lookup(name)
+			if err != nil {
+				grip.Debugf("falling back to running %s: %+v", db.name, err)
+				return fallback.version(name)
+			}
+
+			var versions []string
+			for _, r := range records {
+				if r.installed {
+					versions = append(versions, r.version)
+				}
+			}
+
+			return newestVersion(fallback.scheme, versions, name)
+		},
+	}
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// dpkg: /var/lib/dpkg/status
+//
+////////////////////////////////////////////////////////////////////////
+
+func newDpkgDatabase(status string) *packageDatabase {
+	return &packageDatabase{
+		name:  "dpkg",
+		paths: func() ([]string, error) { return []string{status}, nil },
+		read: func(paths []string) (map[string][]packageRecord, error) {
+			data, err := ioutil.ReadFile(paths[0])
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			return parseDpkgStatus(data), nil
+		},
+	}
+}
+
+// parseDpkgStatus reads the stanzas of a dpkg status file. Packages
+// are indexed by name and by "name:arch". Only packages whose status
+// is "installed" are installed; removed packages with configuration
+// files left behind ("rc" in "dpkg -l") are not.
+func parseDpkgStatus(data []byte) map[string][]packageRecord {
+	out := map[string][]packageRecord{}
+
+	for _, stanza := range splitStanzas(data) {
+		fields := parseStanzaFields(stanza)
+		name := fields["Package"]
+		if name == "" {
+			continue
+		}
+
+		status := strings.Fields(fields["Status"])
+		record := packageRecord{
+			version:   fields["Version"],
+			state:     fields["Status"],
+			installed: len(status) == 3 && status[2] == "installed",
+		}
+
+		out[name] = append(out[name], record)
+		if arch := fields["Architecture"]; arch != "" {
+			out[name+":"+arch] = append(out[name+":"+arch], record)
+		}
+	}
+
+	return out
+}
+
+var stanzaSeparator = regexp.MustCompile(`\n\s*\n`)
+
+func splitStanzas(data []byte) []string {
+	var out []string
+	for _, stanza := range stanzaSeparator.Split(string(data), -1) {
+		if strings.TrimSpace(stanza) != "" {
+			out = append(out, stanza)
+		}
+	}
+
+	return out
+}
+
+// parseStanzaFields parses "Key: value" lines, as in dpkg status files
+// and python package metadata, ignoring continuation lines.
+func parseStanzaFields(stanza string) map[string]string {
+	out := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(stanza))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		if _, ok := out[parts[0]]; !ok {
+			out[parts[0]] = strings.TrimSpace(parts[1])
+		}
+	}
+
+	return out
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// pacman: /var/lib/pacman/local/<name>-<version>/desc
+//
+////////////////////////////////////////////////////////////////////////
+
+func newPacmanDatabase(local string) *packageDatabase {
+	return &packageDatabase{
+		name:  "pacman",
+		paths: func() ([]string, error) { return []string{local}, nil },
+		read: func(paths []string) (map[string][]packageRecord, error) {
+			entries, err := ioutil.ReadDir(paths[0])
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			out := map[string][]packageRecord{}
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					continue
+				}
+
+				data, err := ioutil.ReadFile(filepath.Join(paths[0], entry.Name(), "desc"))
+				if err != nil {
+					grip.Debugf("skipping pacman entry '%s': %+v", entry.Name(), err)
+					continue
+				}
+
+				desc := parsePacmanDesc(data)
+				if name := desc["NAME"]; name != "" {
+					out[name] = append(out[name], packageRecord{
+						version:   desc["VERSION"],
+						state:     "installed",
+						installed: true,
+					})
+				}
+			}
+
+			return out, nil
+		},
+	}
+}
+
+// parsePacmanDesc reads the "%KEY%" sections of a pacman desc file,
+// keeping the first value of each section.
+func parsePacmanDesc(data []byte) map[string]string {
+	out := map[string]string{}
+
+	var key string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			key = ""
+		case strings.HasPrefix(line, "%") && strings.HasSuffix(line, "%") && len(line) > 2:
+			key = strings.Trim(line, "%")
+		case key != "":
+			if _, ok := out[key]; !ok {
+				out[key] = line
+			}
+		}
+	}
+
+	return out
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// pip: *.dist-info/METADATA and *.egg-info/PKG-INFO in site-packages
+//
+////////////////////////////////////////////////////////////////////////
+
+func newPipDatabase(sitePackages func() ([]string, error)) *packageDatabase {
+	return &packageDatabase{
+		name:      "pip",
+		paths:     sitePackages,
+		normalize: normalizePythonPackageName,
+		read: func(paths []string) (map[string][]packageRecord, error) {
+			out := map[string][]packageRecord{}
+			for _, dir := range paths {
+				for name, record := range readSitePackages(dir) {
+					out[name] = append(out[name], record)
+				}
+			}
+
+			return out, nil
+		},
+	}
+}
+
+// normalizePythonPackageName follows PEP 503, so that "Foo_Bar" and
+// "foo-bar" are the same package.
+func normalizePythonPackageName(name string) string {
+	return strings.ToLower(pythonPackageNameSeparators.ReplaceAllString(name, "-"))
+}
+
+var pythonPackageNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+func readSitePackages(dir string) map[string]packageRecord {
+	out := map[string]packageRecord{}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return out
+	}
+
+	for _, entry := range entries {
+		var metadata string
+		switch {
+		case strings.HasSuffix(entry.Name(), ".dist-info"):
+			metadata = filepath.Join(dir, entry.Name(), "METADATA")
+		case strings.HasSuffix(entry.Name(), ".egg-info") && entry.IsDir():
+			metadata = filepath.Join(dir, entry.Name(), "PKG-INFO")
+		case strings.HasSuffix(entry.Name(), ".egg-info"):
+			metadata = filepath.Join(dir, entry.Name())
+		default:
+			continue
+		}
+
+		data, err := ioutil.ReadFile(metadata)
+		if err != nil {
+			grip.Debugf("skipping python package metadata '%s': %+v", metadata, err)
+			continue
+		}
+
+		// only the headers, before the description body
+		if idx := bytes.Index(data, []byte("\n\n")); idx >= 0 {
+			data = data[:idx]
+		}
+
+		fields := parseStanzaFields(string(data))
+		if fields["Name"] == "" {
+			continue
+		}
+
+		name := normalizePythonPackageName(fields["Name"])
+		if _, ok := out[name]; !ok {
+			out[name] = packageRecord{
+				version:   fields["Version"],
+				state:     "installed",
+				installed: true,
+			}
+		}
+	}
+
+	return out
+}
+
+// pythonSitePackages asks a python interpreter for its module search
+// path, so that the native reader sees the same packages as "pip
+// show" run with that interpreter. This runs once per run, rather
+// than once per package.
+func pythonSitePackages(interpreter func() string) func() ([]string, error) {
+	var once sync.Once
+	var paths []string
+	var err error
+
+	return func() ([]string, error) {
+		once.Do(func() {
+			python := interpreter()
+
+			var out []byte
+			out, err = exec.Command(python, "-c",
+				"import sys; print('\\n'.join(p for p in sys.path if p))").Output()
+			if err != nil {
+				err = errors.Wrapf(err, "problem finding module search path for '%s'", python)
+				return
+			}
+
+			for _, path := range strings.Split(string(out), "\n") {
+				if path = strings.TrimSpace(path); path != "" {
+					paths = append(paths, path)
+				}
+			}
+		})
+
+		return paths, err
+	}
+}
+
+// pipInterpreter reads the interpreter from pip's "#!" line, falling
+// back to python3 when pip isn't a python script (e.g. a shim).
+func pipInterpreter() string {
+	pip, err := exec.LookPath("pip")
+	if err != nil {
+		return "python3"
+	}
+
+	f, err := os.Open(pip)
+	if err != nil {
+		return "python3"
+	}
+	defer f.Close()
+
+	line, _ := bufio.NewReader(f).ReadString('\n')
+	if !strings.HasPrefix(line, "#!") {
+		return "python3"
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) > 1 && filepath.Base(fields[0]) == "env" {
+		fields = fields[1:]
+	}
+
+	if len(fields) == 0 || !strings.HasPrefix(filepath.Base(fields[0]), "python") {
+		return "python3"
+	}
+
+	return fields[0]
+}
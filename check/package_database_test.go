@@ -0,0 +1,151 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const dpkgStatusFixture = `Package: openssl
+Status: install ok installed
+Priority: optional
+Architecture: amd64
+Version: 1.1.1f-1ubuntu2
+Description: Secure Sockets Layer toolkit
+ This package contains the openssl binary.
+
+Package: removed-pkg
+Status: deinstall ok config-files
+Architecture: amd64
+Version: 2.0-1
+
+Package: libc6
+Status: install ok installed
+Architecture: amd64
+Version: 2.31-0ubuntu9
+
+Package: libc6
+Status: install ok installed
+Architecture: i386
+Version: 2.31-0ubuntu9
+`
+
+func TestParseDpkgStatus(t *testing.T) {
+	assert := assert.New(t)
+	packages := parseDpkgStatus([]byte(dpkgStatusFixture))
+
+	if assert.Len(packages["openssl"], 1) {
+		assert.Equal("1.1.1f-1ubuntu2", packages["openssl"][0].version)
+		assert.True(packages["openssl"][0].installed)
+	}
+	assert.Len(packages["openssl:amd64"], 1)
+
+	if assert.Len(packages["removed-pkg"], 1) {
+		assert.False(packages["removed-pkg"][0].installed)
+		assert.Equal("deinstall ok config-files", packages["removed-pkg"][0].state)
+	}
+
+	assert.Len(packages["libc6"], 2)
+	assert.Len(packages["libc6:i386"], 1)
+	assert.Len(packages["Description"], 0)
+}
+
+func TestParsePacmanDesc(t *testing.T) {
+	assert := assert.New(t)
+	desc := parsePacmanDesc([]byte("%NAME%\nopenssl\n\n%VERSION%\n1.1.1.w-1\n\n%DEPENDS%\nglibc\nperl\n"))
+
+	assert.Equal("openssl", desc["NAME"])
+	assert.Equal("1.1.1.w-1", desc["VERSION"])
+	assert.Equal("glibc", desc["DEPENDS"])
+}
+
+func TestNormalizePythonPackageName(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("foo-bar", normalizePythonPackageName("Foo_Bar"))
+	assert.Equal("foo-bar", normalizePythonPackageName("foo.-bar"))
+	assert.Equal("requests", normalizePythonPackageName("requests"))
+}
+
+func TestPackageDatabases(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-package-db")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	// dpkg
+	status := filepath.Join(dir, "status")
+	require.NoError(ioutil.WriteFile(status, []byte(dpkgStatusFixture), 0644))
+
+	calls := 0
+	fallback := func(name string) (bool, string) { calls++; return true, "fallback" }
+	checker := nativePackageChecker(newDpkgDatabase(status), fallback)
+
+	ok, msg := checker("openssl")
+	assert.True(ok)
+	assert.Contains(msg, "1.1.1f-1ubuntu2")
+
+	ok, msg = checker("removed-pkg")
+	assert.False(ok, "packages with only config files are not installed")
+	assert.Contains(msg, "config-files")
+
+	ok, _ = checker("missing")
+	assert.False(ok)
+	assert.Equal(0, calls)
+
+	// a missing database uses the fallback
+	ok, msg = nativePackageChecker(newDpkgDatabase(filepath.Join(dir, "none")), fallback)("openssl")
+	assert.True(ok)
+	assert.Equal("fallback", msg)
+	assert.Equal(1, calls)
+
+	// pacman
+	local := filepath.Join(dir, "local")
+	require.NoError(os.MkdirAll(filepath.Join(local, "zlib-1:1.3-1"), 0755))
+	require.NoError(ioutil.WriteFile(filepath.Join(local, "zlib-1:1.3-1", "desc"),
+		[]byte("%NAME%\nzlib\n\n%VERSION%\n1:1.3-1\n"), 0644))
+	require.NoError(ioutil.WriteFile(filepath.Join(local, "ALPM_DB_VERSION"), []byte("9\n"), 0644))
+
+	versioner := nativePackageVersioner(newPacmanDatabase(local), packageVersioner{scheme: "rpm"})
+	version, err := versioner.version("zlib")
+	assert.NoError(err)
+	assert.Equal("1:1.3-1", version)
+	assert.Equal("rpm", versioner.scheme)
+
+	_, err = versioner.version("missing")
+	assert.Error(err)
+
+	// pip
+	site := filepath.Join(dir, "site-packages")
+	require.NoError(os.MkdirAll(filepath.Join(site, "Foo_Bar-1.2.dist-info"), 0755))
+	require.NoError(ioutil.WriteFile(filepath.Join(site, "Foo_Bar-1.2.dist-info", "METADATA"),
+		[]byte("Metadata-Version: 2.1\nName: Foo_Bar\nVersion: 1.2\n\nName: not-a-header\n"), 0644))
+	require.NoError(ioutil.WriteFile(filepath.Join(site, "legacy-0.1.egg-info"),
+		[]byte("Metadata-Version: 1.0\nName: legacy\nVersion: 0.1\n"), 0644))
+
+	db := newPipDatabase(func() ([]string, error) { return []string{site, filepath.Join(dir, "none")}, nil })
+	checker = nativePackageChecker(db, fallback)
+	for _, name := range []string{"foo-bar", "Foo.Bar", "legacy"} {
+		ok, _ = checker(name)
+		assert.True(ok, name)
+	}
+	ok, _ = checker("not-a-header")
+	assert.False(ok)
+
+	// the cache is reloaded when the database changes
+	require.NoError(os.MkdirAll(filepath.Join(site, "new-2.0.dist-info"), 0755))
+	require.NoError(ioutil.WriteFile(filepath.Join(site, "new-2.0.dist-info", "METADATA"),
+		[]byte("Name: new\nVersion: 2.0\n"), 0644))
+	future := time.Now().Add(time.Minute)
+	require.NoError(os.Chtimes(site, future, future))
+
+	ok, _ = checker("new")
+	assert.True(ok)
+	assert.Equal(1, calls)
+}
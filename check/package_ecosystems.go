@@ -0,0 +1,302 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// this is populated in init.go's init(), alongside the
+// packageCheckerRegistry, for package managers that support checking
+// a specific environment (e.g. a conda environment or a python
+// virtualenv) rather than the default.
+var packageEnvironmentRegistry map[string]func(string) (packageChecker, packageVersioner)
+
+// environmentPackageChecker returns the checker and versioner for an
+// environment of the package manager.
+func environmentPackageChecker(manager, env string) (packageChecker, packageVersioner, error) {
+	factory, ok := packageEnvironmentRegistry[manager]
+	if !ok {
+		return nil, packageVersioner{}, errors.Errorf("%s packages do not support environments", manager)
+	}
+
+	checker, versioner := factory(env)
+	return checker, versioner, nil
+}
+
+// versionPackageChecker reports packages as installed if the
+// versioner finds an installed version, for package managers whose
+// commands succeed even when the package isn't installed.
+func versionPackageChecker(manager string, versioner packageVersioner) packageChecker {
+	return func(name string) (bool, string) {
+		version, err := versioner.version(name)
+		if err != nil {
+			return false, fmt.Sprintf("%s package '%s' is not installed (%+v)", manager, name, err)
+		}
+
+		return true, fmt.Sprintf("%s package '%s' version '%s' is installed", manager, name, version)
+	}
+}
+
+// commandVersioner is like packageVersionerFactory, for commands that
+// don't take the package name as their last argument.
+func commandVersioner(scheme string, command func(string) []string, parser func(string, string) []string) packageVersioner {
+	return packageVersioner{
+		scheme: scheme,
+		version: func(name string) (string, error) {
+			args := command(name)
+
+			out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+			output := strings.Trim(string(out), "\r\t\n ")
+			if err != nil {
+				return "", errors.Wrapf(err, "problem running '%s': %s", strings.Join(args, " "), output)
+			}
+
+			return newestVersion(scheme, parser(name, output), name)
+		},
+	}
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// apk: /lib/apk/db/installed
+//
+////////////////////////////////////////////////////////////////////////
+
+var apkDatabase = newApkDatabase("/lib/apk/db/installed")
+
+func newApkDatabase(installed string) *packageDatabase {
+	return &packageDatabase{
+		name:  "apk",
+		paths: func() ([]string, error) { return []string{installed}, nil },
+		read: func(paths []string) (map[string][]packageRecord, error) {
+			data, err := ioutil.ReadFile(paths[0])
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			return parseApkInstalled(data), nil
+		},
+	}
+}
+
+// parseApkInstalled reads the stanzas of apk's installed database,
+// which use single letter keys, e.g. "P:" for the package name and
+// "V:" for the version.
+func parseApkInstalled(data []byte) map[string][]packageRecord {
+	out := map[string][]packageRecord{}
+
+	for _, stanza := range splitStanzas(data) {
+		fields := parseStanzaFields(stanza)
+		if name := fields["P"]; name != "" {
+			out[name] = append(out[name], packageRecord{
+				version:   fields["V"],
+				state:     "installed",
+				installed: true,
+			})
+		}
+	}
+
+	return out
+}
+
+// parseApkInfoVersions parses the output of "apk info -e -v", which
+// prints "<name>-<version>" for installed packages.
+func parseApkInfoVersions(name, output string) []string {
+	var out []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, name+"-") {
+			out = append(out, strings.TrimPrefix(line, name+"-"))
+		}
+	}
+
+	return out
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// python environments (pip) and conda
+//
+////////////////////////////////////////////////////////////////////////
+
+// pythonForEnvironment returns the interpreter for a pip environment,
+// which is either the path to (or name of) a python interpreter, or
+// the directory of a virtualenv or conda environment.
+func pythonForEnvironment(env string) string {
+	if stat, err := os.Stat(env); err == nil && stat.IsDir() {
+		if runtime.GOOS != "windows" {
+			return filepath.Join(env, "bin", "python")
+		}
+
+		// virtualenvs put python in Scripts, while conda
+		// environments have it in the root.
+		python := filepath.Join(env, "Scripts", "python.exe")
+		if _, err = os.Stat(python); err == nil {
+			return python
+		}
+		return filepath.Join(env, "python.exe")
+	}
+
+	return env
+}
+
+var (
+	pipEnvironmentDatabasesMutex sync.Mutex
+	pipEnvironmentDatabases      = map[string]*packageDatabase{}
+)
+
+// pipEnvironment checks packages installed for a specific python
+// interpreter. The packages for each interpreter are only loaded
+// once.
+func pipEnvironment(env string) (packageChecker, packageVersioner) {
+	python := pythonForEnvironment(env)
+
+	pipEnvironmentDatabasesMutex.Lock()
+	db, ok := pipEnvironmentDatabases[python]
+	if !ok {
+		db = newPipDatabase(pythonSitePackages(func() string { return python }))
+		pipEnvironmentDatabases[python] = db
+	}
+	pipEnvironmentDatabasesMutex.Unlock()
+
+	args := []string{python, "-m", "pip", "show"}
+	return nativePackageChecker(db, packageCheckerFactory(args)),
+		nativePackageVersioner(db, packageVersionerFactory("pep440", args, parsePipShowVersion))
+}
+
+// condaListCommand returns the arguments to list a package in a conda
+// environment, given by name, or by path when the environment
+// includes a path separator.
+func condaListCommand(env string) func(string) []string {
+	return func(name string) []string {
+		args := []string{"conda", "list", "--json", "--full-name"}
+		if strings.ContainsRune(env, '/') || strings.ContainsRune(env, filepath.Separator) {
+			args = append(args, "--prefix", env)
+		} else if env != "" {
+			args = append(args, "--name", env)
+		}
+
+		return append(args, name)
+	}
+}
+
+func condaEnvironment(env string) (packageChecker, packageVersioner) {
+	versioner := commandVersioner("pep440", condaListCommand(env), parseCondaListVersions)
+	return versionPackageChecker("conda", versioner), versioner
+}
+
+// parseCondaListVersions parses the JSON output of "conda list".
+func parseCondaListVersions(name, output string) []string {
+	var packages []struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	if err := json.Unmarshal([]byte(output), &packages); err != nil {
+		return nil
+	}
+
+	var out []string
+	for _, pkg := range packages {
+		if pkg.Name == name {
+			out = append(out, pkg.Version)
+		}
+	}
+
+	return out
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// npm (global), cargo, and go installed tools
+//
+////////////////////////////////////////////////////////////////////////
+
+var (
+	npmVersioner = packageVersionerFactory("semver",
+		[]string{"npm", "ls", "--global", "--depth=0", "--json"}, parseNpmListVersions)
+	cargoVersioner = commandVersioner("semver",
+		func(string) []string { return []string{"cargo", "install", "--list"} }, parseCargoInstallList)
+	goToolsVersioner = commandVersioner("loose",
+		func(string) []string { return goInstalledToolsCommand }, parseGoVersionModules)
+)
+
+// parseNpmListVersions parses the JSON output of "npm ls -g --json".
+func parseNpmListVersions(name, output string) []string {
+	var tree struct {
+		Dependencies map[string]struct {
+			Version string `json:"version"`
+		} `json:"dependencies"`
+	}
+
+	if err := json.Unmarshal([]byte(output), &tree); err != nil {
+		return nil
+	}
+
+	if dep, ok := tree.Dependencies[name]; ok && dep.Version != "" {
+		return []string{dep.Version}
+	}
+
+	return nil
+}
+
+// parseCargoInstallList parses the output of "cargo install --list",
+// which has lines of the form "<name> v<version>:" or
+// "<name> v<version> (<source>):" for each installed crate.
+func parseCargoInstallList(name, output string) []string {
+	var out []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, " ") || !strings.HasSuffix(line, ":") {
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimSuffix(line, ":"))
+		if len(fields) >= 2 && fields[0] == name {
+			out = append(out, strings.TrimPrefix(fields[1], "v"))
+		}
+	}
+
+	return out
+}
+
+// goInstalledToolsCommand reports the build information for all
+// binaries installed with "go install".
+var goInstalledToolsCommand = []string{"sh", "-c",
+	`dir=$(go env GOBIN); [ -n "$dir" ] || dir="$(go env GOPATH | cut -d: -f1)/bin"; go version -m "$dir"`}
+
+// parseGoVersionModules parses the output of "go version -m". Tools
+// match by binary name, package path, or module path.
+func parseGoVersionModules(name, output string) []string {
+	var out []string
+	var binary, pkg string
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, "\t") {
+			if idx := strings.LastIndex(line, ":"); idx > 0 {
+				binary = strings.TrimSuffix(filepath.Base(line[:idx]), ".exe")
+			}
+			pkg = ""
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) >= 2 && fields[0] == "path":
+			pkg = fields[1]
+		case len(fields) >= 3 && fields[0] == "mod":
+			if name == binary || name == pkg || name == fields[1] {
+				out = append(out, fields[2])
+			}
+		}
+	}
+
+	return out
+}
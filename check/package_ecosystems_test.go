@@ -0,0 +1,130 @@
+package check
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageEcosystemParsers(t *testing.T) {
+	assert := assert.New(t)
+
+	apk := parseApkInstalled([]byte("C:Q1abc=\nP:musl\nV:1.2.4-r2\nA:x86_64\n\nC:Q1def=\nP:openssl\nV:3.1.4-r5\n"))
+	if assert.Len(apk["musl"], 1) {
+		assert.Equal("1.2.4-r2", apk["musl"][0].version)
+		assert.True(apk["musl"][0].installed)
+	}
+	assert.Len(apk["openssl"], 1)
+
+	assert.Equal([]string{"3.1.4-r5"}, parseApkInfoVersions("openssl", "openssl-3.1.4-r5\n"))
+	assert.Len(parseApkInfoVersions("openssl", ""), 0)
+
+	conda := `[{"base_url": "https://repo.anaconda.com/pkgs/main", "name": "numpy", "version": "1.26.4"},
+		{"name": "numpy-base", "version": "1.26.4"}]`
+	assert.Equal([]string{"1.26.4"}, parseCondaListVersions("numpy", conda))
+	assert.Len(parseCondaListVersions("scipy", conda), 0)
+	assert.Len(parseCondaListVersions("numpy", "not json"), 0)
+
+	npm := `{"name": "lib", "dependencies": {"typescript": {"version": "5.4.5", "overridden": false}}}`
+	assert.Equal([]string{"5.4.5"}, parseNpmListVersions("typescript", npm))
+	assert.Len(parseNpmListVersions("eslint", npm), 0)
+
+	cargo := "ripgrep v14.1.0:\n    rg\ncargo-local v0.1.0 (/src/cargo-local):\n    cargo-local\n"
+	assert.Equal([]string{"14.1.0"}, parseCargoInstallList("ripgrep", cargo))
+	assert.Equal([]string{"0.1.0"}, parseCargoInstallList("cargo-local", cargo))
+	assert.Len(parseCargoInstallList("rg", cargo), 0)
+
+	gotools := "/root/go/bin/gopls: go1.22.1\n" +
+		"\tpath\tgolang.org/x/tools/gopls\n" +
+		"\tmod\tgolang.org/x/tools/gopls\tv0.15.2\th1:abc=\n" +
+		"\tdep\tgolang.org/x/mod\tv0.16.0\th1:def=\n" +
+		"/root/go/bin/staticcheck: go1.22.1\n" +
+		"\tpath\thonnef.co/go/tools/cmd/staticcheck\n" +
+		"\tmod\thonnef.co/go/tools\tv0.4.7\th1:ghi=\n"
+	assert.Equal([]string{"v0.15.2"}, parseGoVersionModules("gopls", gotools))
+	assert.Equal([]string{"v0.15.2"}, parseGoVersionModules("golang.org/x/tools/gopls", gotools))
+	assert.Equal([]string{"v0.4.7"}, parseGoVersionModules("staticcheck", gotools))
+	assert.Equal([]string{"v0.4.7"}, parseGoVersionModules("honnef.co/go/tools", gotools))
+	assert.Len(parseGoVersionModules("golang.org/x/mod", gotools), 0, "dependencies are not installed tools")
+}
+
+func TestPackageEnvironments(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-env")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	if runtime.GOOS != "windows" {
+		assert.Equal(filepath.Join(dir, "bin", "python"), pythonForEnvironment(dir))
+	}
+	assert.Equal("python3.11", pythonForEnvironment("python3.11"))
+	assert.Equal("/usr/bin/python3", pythonForEnvironment("/usr/bin/python3"))
+
+	assert.Equal([]string{"conda", "list", "--json", "--full-name", "numpy"}, condaListCommand("")("numpy"))
+	assert.Equal([]string{"conda", "list", "--json", "--full-name", "--name", "py311", "numpy"},
+		condaListCommand("py311")("numpy"))
+	assert.Equal([]string{"conda", "list", "--json", "--full-name", "--prefix", "/opt/envs/x", "numpy"},
+		condaListCommand("/opt/envs/x")("numpy"))
+
+	_, _, err = environmentPackageChecker("dpkg", "foo")
+	assert.Error(err)
+
+	for _, manager := range []string{"pip", "conda"} {
+		checker, versioner, err := environmentPackageChecker(manager, dir)
+		assert.NoError(err)
+		assert.NotNil(checker)
+		assert.NotNil(versioner.version)
+	}
+
+	// pip environments share a database per interpreter
+	pipEnvironment(dir)
+	pipEnvironment(dir)
+	assert.Contains(pipEnvironmentDatabases, filepath.Join(dir, "bin", "python"))
+
+	// environments for package managers that don't support them are errors
+	check := &packageInstalled{
+		Package:     "openssl",
+		Environment: "foo",
+		manager:     "dpkg",
+		checker:     packageCheckerRegistry["dpkg"],
+		Base:        NewBase("dpkg-installed", 0),
+		installed:   true,
+	}
+	check.Run(context.Background())
+	assert.False(check.Output().Passed)
+	assert.Error(check.Error())
+
+	// a missing environment means that packages are not installed
+	check = &packageInstalled{
+		Package:     "requests",
+		Environment: dir,
+		manager:     "pip",
+		checker:     packageCheckerRegistry["pip"],
+		Base:        NewBase("pip-installed", 0),
+		installed:   true,
+	}
+	check.Run(context.Background())
+	assert.False(check.Output().Passed)
+}
+
+func TestVersionPackageChecker(t *testing.T) {
+	assert := assert.New(t)
+
+	checker := versionPackageChecker("cargo", commandVersioner("semver",
+		func(string) []string { return []string{"echo", "ripgrep v14.1.0:"} }, parseCargoInstallList))
+
+	ok, msg := checker("ripgrep")
+	assert.True(ok)
+	assert.Contains(msg, "14.1.0")
+
+	ok, msg = checker("fd-find")
+	assert.False(ok)
+	assert.Contains(msg, "not installed")
+}
@@ -10,12 +10,13 @@ import (
 )
 
 func registerPackageGroupChecks() {
-	packageGroupFactoryFactory := func(name string, gr GroupRequirements, checker packageChecker) func() amboy.Job {
+	packageGroupFactoryFactory := func(name, manager string, gr GroupRequirements, checker packageChecker) func() amboy.Job {
 		return func() amboy.Job {
 			gr.Name = name
 			return &packageGroup{
 				Base:         NewBase(name, 0),
 				Requirements: gr,
+				manager:      manager,
 				checker:      checker,
 			}
 		}
@@ -24,16 +25,64 @@ func registerPackageGroupChecks() {
 	for pkg, checker := range packageCheckerRegistry {
 		for group, requirements := range groupRequirementRegistry {
 			name := fmt.Sprintf("%s-group-%s", pkg, group)
-			registry.AddJobType(name, packageGroupFactoryFactory(name, requirements, checker))
+			registry.AddJobType(name, packageGroupFactoryFactory(name, pkg, requirements, checker))
 		}
 	}
+
+	// the package manager for these checks is detected when they
+	// run, from the system's os-release file.
+	systemPackageGroupFactoryFactory := func(name string, gr GroupRequirements) func() amboy.Job {
+		return func() amboy.Job {
+			gr.Name = name
+			return &packageGroup{
+				Base:         NewBase(name, 0),
+				Requirements: gr,
+				osRelease:    defaultOSRelease,
+			}
+		}
+	}
+
+	for group, requirements := range groupRequirementRegistry {
+		name := fmt.Sprintf("package-group-%s", group)
+		registry.AddJobType(name, systemPackageGroupFactoryFactory(name, requirements))
+	}
 }
 
+// packageGroup asserts that a group of packages are installed. As
+// with packageInstalled, the "package-group-*" checks detect the
+// package manager, and take lists of package names per distribution
+// or package manager, with the packages field as the default. For pip
+// and conda, the environment selects the environment to check.
 type packageGroup struct {
-	Packages     []string          `bson:"packages" json:"packages" yaml:"packages"`
-	Requirements GroupRequirements `bson:"requirements" json:"requirements" yaml:"requirements"`
+	Packages     []string            `bson:"packages" json:"packages" yaml:"packages"`
+	Names        map[string][]string `bson:"names" json:"names" yaml:"names"`
+	Environment  string              `bson:"environment" json:"environment" yaml:"environment"`
+	Requirements GroupRequirements   `bson:"requirements" json:"requirements" yaml:"requirements"`
 	*Base        `bson:"metadata" json:"metadata" yaml:"metadata"`
+	manager      string
 	checker      packageChecker
+	osRelease    string
+}
+
+func (c *packageGroup) detectPackageManager() error {
+	if c.checker != nil {
+		return nil
+	}
+
+	manager, ids, err := detectSystemPackageManager(c.osRelease)
+	if err != nil {
+		return err
+	}
+
+	c.manager = manager
+	c.checker = packageCheckerRegistry[manager]
+
+	key, ok := systemPackageNameKey(ids, manager, func(k string) bool { _, ok := c.Names[k]; return ok })
+	if ok {
+		c.Packages = c.Names[key]
+	}
+
+	return nil
 }
 
 func (c *packageGroup) Run(_ context.Context) {
@@ -46,6 +95,22 @@ func (c *packageGroup) Run(_ context.Context) {
 		return
 	}
 
+	if err := c.detectPackageManager(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	if c.Environment != "" {
+		var err error
+		c.checker, _, err = environmentPackageChecker(c.manager, c.Environment)
+		if err != nil {
+			c.setState(false)
+			c.AddError(err)
+			return
+		}
+	}
+
 	if len(c.Packages) == 0 {
 		c.setState(false)
 		c.AddError(errors.Errorf("no packages for '%s' (%s) check",
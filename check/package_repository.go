@@ -0,0 +1,472 @@
+package check
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "package-repository"
+	registry.AddJobType(name, func() amboy.Job {
+		return &packageRepositoryCheck{
+			Base:           NewBase(name, 0), // (name, version)
+			aptSourcesList: "/etc/apt/sources.list",
+			aptSourcesDir:  "/etc/apt/sources.list.d",
+			yumReposDir:    "/etc/yum.repos.d",
+			yumConfigs:     []string{"/etc/dnf/dnf.conf", "/etc/yum.conf"},
+		}
+	})
+}
+
+// packageRepository is a repository definition read from apt or yum
+// configuration.
+type packageRepository struct {
+	source     string
+	id         string
+	urls       []string
+	suites     []string
+	components []string
+	enabled    bool
+	gpgcheck   bool
+	signedBy   string
+}
+
+func (r packageRepository) String() string {
+	return fmt.Sprintf("'%s' [%s] in '%s'", r.id, strings.Join(r.urls, ", "), r.source)
+}
+
+// packageRepositoryCheck asserts that an apt or yum/dnf repository,
+// identified by base URL or id, is configured and enabled. For apt,
+// the id is the name of the sources file without its extension, and
+// for yum it is the section name in the .repo file. Optionally, the
+// repository must have signature checking enabled (gpgcheck for yum,
+// a signed-by option for apt), or use a specific key.
+type packageRepositoryCheck struct {
+	Format     string   `bson:"format" json:"format" yaml:"format"`
+	URL        string   `bson:"url" json:"url" yaml:"url"`
+	Repository string   `bson:"id" json:"id" yaml:"id"`
+	Suite      string   `bson:"suite" json:"suite" yaml:"suite"`
+	Components []string `bson:"components" json:"components" yaml:"components"`
+	Enabled    *bool    `bson:"enabled" json:"enabled" yaml:"enabled"`
+	GPGCheck   bool     `bson:"gpgcheck" json:"gpgcheck" yaml:"gpgcheck"`
+	SignedBy   string   `bson:"signed_by" json:"signed_by" yaml:"signed_by"`
+	*Base      `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	aptSourcesList string
+	aptSourcesDir  string
+	yumReposDir    string
+	yumConfigs     []string
+}
+
+func (c *packageRepositoryCheck) validate() error {
+	if c.URL == "" && c.Repository == "" {
+		return errors.Errorf("no repository url or id specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	if c.Format == "" {
+		if _, err := os.Stat(c.aptSourcesList); err == nil {
+			c.Format = "apt"
+		} else if _, err = os.Stat(c.aptSourcesDir); err == nil {
+			c.Format = "apt"
+		} else {
+			c.Format = "yum"
+		}
+		grip.Debugf("using %s repository configuration for '%s'", c.Format, c.ID())
+	}
+
+	if c.Format != "apt" && c.Format != "yum" {
+		return errors.Errorf("repository format '%s' is not valid, must be 'apt' or 'yum'", c.Format)
+	}
+
+	if c.Enabled == nil {
+		enabled := true
+		c.Enabled = &enabled
+	}
+
+	return nil
+}
+
+func (c *packageRepositoryCheck) Run(_ context.Context) {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	var repos []packageRepository
+	var err error
+	if c.Format == "apt" {
+		repos, err = readAptRepositories(c.aptSourcesList, c.aptSourcesDir)
+	} else {
+		repos, err = readYumRepositories(c.yumReposDir, c.yumConfigs)
+	}
+
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	var problems []string
+	for _, repo := range repos {
+		if !c.matches(repo) {
+			continue
+		}
+
+		problem := c.problem(repo)
+		if problem == "" {
+			c.setMessage(fmt.Sprintf("repository %s satisfies the check", repo))
+			c.setState(true)
+			return
+		}
+
+		problems = append(problems, fmt.Sprintf("repository %s %s", repo, problem))
+	}
+
+	c.setState(false)
+	if len(problems) == 0 {
+		c.AddError(errors.Errorf("no %s repository with url='%s' id='%s' is configured (%d repositories)",
+			c.Format, c.URL, c.Repository, len(repos)))
+		return
+	}
+
+	c.setMessage(problems)
+	c.AddError(errors.Errorf("no configured %s repository with url='%s' id='%s' satisfies the check",
+		c.Format, c.URL, c.Repository))
+}
+
+func normalizeRepositoryURL(url string) string {
+	return strings.TrimRight(strings.TrimSpace(url), "/")
+}
+
+func (c *packageRepositoryCheck) matches(repo packageRepository) bool {
+	if c.Repository != "" && repo.id != c.Repository {
+		return false
+	}
+
+	if c.URL == "" {
+		return true
+	}
+
+	for _, url := range repo.urls {
+		if normalizeRepositoryURL(url) == normalizeRepositoryURL(c.URL) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// problem returns a description of why a matching repository doesn't
+// satisfy the check, or an empty string if it does.
+func (c *packageRepositoryCheck) problem(repo packageRepository) string {
+	if repo.enabled != *c.Enabled {
+		return fmt.Sprintf("has enabled=%t, expected %t", repo.enabled, *c.Enabled)
+	}
+
+	if c.Suite != "" && !stringSliceContains(repo.suites, c.Suite) {
+		return fmt.Sprintf("does not include suite '%s' (%s)", c.Suite, strings.Join(repo.suites, ", "))
+	}
+
+	for _, component := range c.Components {
+		if !stringSliceContains(repo.components, component) {
+			return fmt.Sprintf("does not include component '%s' (%s)", component, strings.Join(repo.components, ", "))
+		}
+	}
+
+	if c.GPGCheck && !repo.gpgcheck {
+		return "does not check signatures"
+	}
+
+	if c.SignedBy != "" && !strings.Contains(repo.signedBy, c.SignedBy) {
+		return fmt.Sprintf("is signed by '%s', expected '%s'", repo.signedBy, c.SignedBy)
+	}
+
+	return ""
+}
+
+func stringSliceContains(slice []string, value string) bool {
+	for _, v := range slice {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// apt: sources.list, sources.list.d/*.list, and deb822 *.sources
+//
+////////////////////////////////////////////////////////////////////////
+
+func readAptRepositories(sourcesList, sourcesDir string) ([]packageRepository, error) {
+	files := []string{sourcesList}
+	for _, pattern := range []string{"*.list", "*.sources"} {
+		matches, err := filepath.Glob(filepath.Join(sourcesDir, pattern))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		files = append(files, matches...)
+	}
+
+	var out []packageRepository
+	catcher := grip.NewCatcher()
+	read := 0
+	for _, fn := range files {
+		data, err := ioutil.ReadFile(fn)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			catcher.Add(errors.Wrapf(err, "problem reading '%s'", fn))
+			continue
+		}
+		read++
+
+		id := strings.TrimSuffix(filepath.Base(fn), filepath.Ext(fn))
+		if strings.HasSuffix(fn, ".sources") {
+			out = append(out, parseDeb822Sources(fn, id, data)...)
+		} else {
+			out = append(out, parseAptSourcesList(fn, id, data)...)
+		}
+	}
+
+	if read == 0 && !catcher.HasErrors() {
+		catcher.Add(errors.Errorf("no apt sources found in '%s' or '%s'", sourcesList, sourcesDir))
+	}
+
+	return out, catcher.Resolve()
+}
+
+// parseAptSourcesList parses one-line-style entries, e.g.
+// "deb [arch=amd64 signed-by=/usr/share/keyrings/x.gpg] http://host/ubuntu jammy main".
+// Commented out entries are reported as disabled.
+func parseAptSourcesList(fn, id string, data []byte) []packageRepository {
+	var out []packageRepository
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		enabled := true
+		if strings.HasPrefix(line, "#") {
+			enabled = false
+			line = strings.TrimSpace(strings.TrimLeft(line, "#"))
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 3 || (fields[0] != "deb" && fields[0] != "deb-src") {
+			continue
+		}
+
+		options := map[string]string{}
+		fields = fields[1:]
+		if strings.HasPrefix(fields[0], "[") {
+			end := 0
+			for end < len(fields) && !strings.HasSuffix(fields[end], "]") {
+				end++
+			}
+			if end == len(fields) {
+				continue
+			}
+
+			for _, opt := range fields[:end+1] {
+				opt = strings.Trim(opt, "[]")
+				if kv := strings.SplitN(opt, "=", 2); len(kv) == 2 {
+					options[kv[0]] = kv[1]
+				}
+			}
+			fields = fields[end+1:]
+		}
+
+		if len(fields) < 2 {
+			continue
+		}
+
+		signedBy, signed := options["signed-by"]
+		out = append(out, packageRepository{
+			source:     fn,
+			id:         id,
+			urls:       fields[:1],
+			suites:     fields[1:2],
+			components: fields[2:],
+			enabled:    enabled,
+			gpgcheck:   signed,
+			signedBy:   signedBy,
+		})
+	}
+
+	return out
+}
+
+// parseDeb822Sources parses the stanzas of a deb822 style sources
+// file. Field names are case insensitive, and the Signed-By field may
+// be a path or an inline key.
+func parseDeb822Sources(fn, id string, data []byte) []packageRepository {
+	var out []packageRepository
+
+	for _, stanza := range splitStanzas(data) {
+		fields := map[string]string{}
+		for key, value := range parseStanzaFields(stanza) {
+			fields[strings.ToLower(key)] = value
+		}
+
+		if fields["uris"] == "" {
+			continue
+		}
+
+		signedBy, signed := fields["signed-by"]
+		if signed && signedBy == "" {
+			signedBy = "(inline key)"
+		}
+
+		out = append(out, packageRepository{
+			source:     fn,
+			id:         id,
+			urls:       strings.Fields(fields["uris"]),
+			suites:     strings.Fields(fields["suites"]),
+			components: strings.Fields(fields["components"]),
+			enabled:    fields["enabled"] == "" || fields["enabled"] == "yes",
+			gpgcheck:   signed,
+			signedBy:   signedBy,
+		})
+	}
+
+	return out
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// yum/dnf: /etc/yum.repos.d/*.repo
+//
+////////////////////////////////////////////////////////////////////////
+
+func readYumRepositories(reposDir string, configs []string) ([]packageRepository, error) {
+	// gpgcheck defaults to the [main] setting in the yum or dnf
+	// configuration.
+	defaultGPGCheck := false
+	for _, fn := range configs {
+		data, err := ioutil.ReadFile(fn)
+		if err != nil {
+			continue
+		}
+
+		if value, ok := parseYumRepoFile(data)["main"]["gpgcheck"]; ok {
+			defaultGPGCheck = yumBool(value)
+		}
+		break
+	}
+
+	files, err := filepath.Glob(filepath.Join(reposDir, "*.repo"))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(files) == 0 {
+		return nil, errors.Errorf("no yum repositories found in '%s'", reposDir)
+	}
+
+	var out []packageRepository
+	catcher := grip.NewCatcher()
+	for _, fn := range files {
+		data, err := ioutil.ReadFile(fn)
+		if err != nil {
+			catcher.Add(errors.Wrapf(err, "problem reading '%s'", fn))
+			continue
+		}
+
+		for id, section := range parseYumRepoFile(data) {
+			repo := packageRepository{
+				source:   fn,
+				id:       id,
+				enabled:  true,
+				gpgcheck: defaultGPGCheck,
+				signedBy: section["gpgkey"],
+			}
+
+			for _, key := range []string{"baseurl", "mirrorlist", "metalink"} {
+				repo.urls = append(repo.urls, strings.FieldsFunc(section[key], func(r rune) bool {
+					return r == ',' || r == ' ' || r == '\n'
+				})...)
+			}
+
+			if value, ok := section["enabled"]; ok {
+				repo.enabled = yumBool(value)
+			}
+
+			if value, ok := section["gpgcheck"]; ok {
+				repo.gpgcheck = yumBool(value)
+			}
+
+			out = append(out, repo)
+		}
+	}
+
+	return out, catcher.Resolve()
+}
+
+// parseYumRepoFile parses the INI format used by yum and dnf, where
+// indented lines continue the previous value (e.g. several baseurls).
+func parseYumRepoFile(data []byte) map[string]map[string]string {
+	out := map[string]map[string]string{}
+	var section map[string]string
+	var key string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = map[string]string{}
+			out[strings.TrimSpace(line[1:len(line)-1])] = section
+			key = ""
+			continue
+		}
+
+		if section == nil {
+			continue
+		}
+
+		if (raw[0] == ' ' || raw[0] == '\t') && key != "" {
+			section[key] += "\n" + line
+			continue
+		}
+
+		idx := strings.Index(line, "=")
+		if idx < 0 {
+			continue
+		}
+
+		key = strings.TrimSpace(line[:idx])
+		section[key] = strings.TrimSpace(line[idx+1:])
+	}
+
+	return out
+}
+
+func yumBool(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "yes", "true", "on":
+		return true
+	default:
+		return false
+	}
+}
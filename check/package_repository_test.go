@@ -0,0 +1,235 @@
+package check
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	aptSourcesListFixture = `# main archive
+deb http://deb.debian.org/debian bookworm main contrib
+deb-src http://deb.debian.org/debian bookworm main
+# deb http://deb.debian.org/debian bookworm-backports main
+`
+	aptListFileFixture = `deb [arch=amd64 signed-by=/usr/share/keyrings/mongodb.gpg] https://repo.mongodb.org/apt/debian/ bookworm/mongodb-org/7.0 main
+`
+	deb822SourcesFixture = `Types: deb
+URIs: https://download.docker.com/linux/debian
+Suites: bookworm
+Components: stable
+Signed-By: /etc/apt/keyrings/docker.asc
+
+Types: deb deb-src
+URIs: http://security.debian.org/debian-security
+Suites: bookworm-security
+Components: main
+Enabled: no
+`
+	yumRepoFixture = `[mongodb-org-7.0]
+name=MongoDB Repository
+baseurl=https://repo.mongodb.org/yum/redhat/9/mongodb-org/7.0/x86_64/
+gpgcheck=1
+enabled=1
+gpgkey=https://pgp.mongodb.com/server-7.0.asc
+
+[epel]
+name=Extra Packages
+metalink=https://mirrors.fedoraproject.org/metalink?repo=epel-9&arch=x86_64
+enabled=0
+
+[internal]
+name=Internal
+baseurl=http://mirror1.example.net/internal/
+  http://mirror2.example.net/internal/
+`
+)
+
+func writePackageRepositoryFixtures(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "greenbay-package-repository")
+	require.NoError(t, err)
+
+	for fn, content := range map[string]string{
+		"sources.list":                     aptSourcesListFixture,
+		"sources.list.d/mongodb.list":      aptListFileFixture,
+		"sources.list.d/docker.sources":    deb822SourcesFixture,
+		"sources.list.d/ignored.save":      "deb http://example.net/ignored stable main\n",
+		"yum.repos.d/mongodb.repo":         yumRepoFixture,
+		"yum.conf":                         "[main]\ngpgcheck=1\n",
+		"yum.repos.d/ignored.repo.rpmsave": "[ignored]\nbaseurl=http://example.net/\n",
+	} {
+		path := filepath.Join(dir, fn)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	return dir
+}
+
+func newPackageRepositoryFixtureCheck(dir string) *packageRepositoryCheck {
+	return &packageRepositoryCheck{
+		Base:           NewBase("package-repository", 0),
+		aptSourcesList: filepath.Join(dir, "sources.list"),
+		aptSourcesDir:  filepath.Join(dir, "sources.list.d"),
+		yumReposDir:    filepath.Join(dir, "yum.repos.d"),
+		yumConfigs:     []string{filepath.Join(dir, "yum.conf")},
+	}
+}
+
+func TestParseAptRepositories(t *testing.T) {
+	assert := assert.New(t)
+	dir := writePackageRepositoryFixtures(t)
+	defer os.RemoveAll(dir)
+
+	repos, err := readAptRepositories(filepath.Join(dir, "sources.list"), filepath.Join(dir, "sources.list.d"))
+	assert.NoError(err)
+	assert.Len(repos, 6)
+
+	byURL := map[string]packageRepository{}
+	for _, repo := range repos {
+		// keep the first entry, i.e. deb rather than deb-src
+		key := repo.urls[0] + " " + repo.suites[0]
+		if _, ok := byURL[key]; !ok {
+			byURL[key] = repo
+		}
+	}
+
+	main := byURL["http://deb.debian.org/debian bookworm"]
+	assert.True(main.enabled)
+	assert.Equal("sources", main.id)
+	assert.Equal([]string{"main", "contrib"}, main.components)
+	assert.False(main.gpgcheck)
+
+	assert.False(byURL["http://deb.debian.org/debian bookworm-backports"].enabled)
+
+	mongodb := byURL["https://repo.mongodb.org/apt/debian/ bookworm/mongodb-org/7.0"]
+	assert.Equal("mongodb", mongodb.id)
+	assert.True(mongodb.gpgcheck)
+	assert.Equal("/usr/share/keyrings/mongodb.gpg", mongodb.signedBy)
+
+	docker := byURL["https://download.docker.com/linux/debian bookworm"]
+	assert.Equal("docker", docker.id)
+	assert.True(docker.enabled)
+	assert.Equal("/etc/apt/keyrings/docker.asc", docker.signedBy)
+
+	security := byURL["http://security.debian.org/debian-security bookworm-security"]
+	assert.False(security.enabled)
+	assert.False(security.gpgcheck)
+
+	_, err = readAptRepositories(filepath.Join(dir, "missing"), filepath.Join(dir, "missing.d"))
+	assert.Error(err)
+}
+
+func TestParseYumRepositories(t *testing.T) {
+	assert := assert.New(t)
+	dir := writePackageRepositoryFixtures(t)
+	defer os.RemoveAll(dir)
+
+	repos, err := readYumRepositories(filepath.Join(dir, "yum.repos.d"), []string{filepath.Join(dir, "yum.conf")})
+	assert.NoError(err)
+	assert.Len(repos, 3)
+
+	byID := map[string]packageRepository{}
+	for _, repo := range repos {
+		byID[repo.id] = repo
+	}
+
+	assert.True(byID["mongodb-org-7.0"].enabled)
+	assert.True(byID["mongodb-org-7.0"].gpgcheck)
+	assert.Equal("https://pgp.mongodb.com/server-7.0.asc", byID["mongodb-org-7.0"].signedBy)
+
+	assert.False(byID["epel"].enabled)
+	assert.Len(byID["epel"].urls, 1)
+
+	// gpgcheck comes from yum.conf, and baseurl continues on the
+	// indented line.
+	assert.True(byID["internal"].gpgcheck)
+	assert.Equal([]string{"http://mirror1.example.net/internal/", "http://mirror2.example.net/internal/"},
+		byID["internal"].urls)
+
+	repos, err = readYumRepositories(filepath.Join(dir, "yum.repos.d"), nil)
+	assert.NoError(err)
+	for _, repo := range repos {
+		if repo.id == "internal" {
+			assert.False(repo.gpgcheck)
+		}
+	}
+
+	_, err = readYumRepositories(filepath.Join(dir, "missing"), nil)
+	assert.Error(err)
+}
+
+func TestPackageRepositoryCheck(t *testing.T) {
+	assert := assert.New(t)
+	dir := writePackageRepositoryFixtures(t)
+	defer os.RemoveAll(dir)
+
+	disabled := false
+	for idx, test := range []struct {
+		passes bool
+		setup  func(*packageRepositoryCheck)
+	}{
+		{false, func(c *packageRepositoryCheck) { c.Format = "apt" }},
+		{false, func(c *packageRepositoryCheck) { c.Format = "zypper"; c.Repository = "mongodb" }},
+		{true, func(c *packageRepositoryCheck) { c.Format = "apt"; c.URL = "http://deb.debian.org/debian/" }},
+		{true, func(c *packageRepositoryCheck) {
+			c.Format = "apt"
+			c.URL = "http://deb.debian.org/debian"
+			c.Suite = "bookworm"
+			c.Components = []string{"contrib"}
+		}},
+		{false, func(c *packageRepositoryCheck) {
+			c.Format = "apt"
+			c.URL = "http://deb.debian.org/debian"
+			c.Suite = "bookworm-backports"
+		}},
+		{true, func(c *packageRepositoryCheck) {
+			c.Format = "apt"
+			c.URL = "http://deb.debian.org/debian"
+			c.Suite = "bookworm-backports"
+			c.Enabled = &disabled
+		}},
+		{false, func(c *packageRepositoryCheck) {
+			c.Format = "apt"
+			c.URL = "http://deb.debian.org/debian"
+			c.GPGCheck = true
+		}},
+		{true, func(c *packageRepositoryCheck) {
+			c.Format = "apt"
+			c.Repository = "mongodb"
+			c.SignedBy = "mongodb.gpg"
+		}},
+		{true, func(c *packageRepositoryCheck) { c.Format = "apt"; c.Repository = "docker"; c.GPGCheck = true }},
+		{false, func(c *packageRepositoryCheck) {
+			c.Format = "apt"
+			c.URL = "http://security.debian.org/debian-security"
+		}},
+		{false, func(c *packageRepositoryCheck) { c.Format = "apt"; c.URL = "http://example.net/ignored" }},
+		{true, func(c *packageRepositoryCheck) { c.Format = "yum"; c.Repository = "mongodb-org-7.0"; c.GPGCheck = true }},
+		{true, func(c *packageRepositoryCheck) {
+			c.Format = "yum"
+			c.URL = "https://repo.mongodb.org/yum/redhat/9/mongodb-org/7.0/x86_64"
+			c.SignedBy = "server-7.0.asc"
+		}},
+		{false, func(c *packageRepositoryCheck) { c.Format = "yum"; c.Repository = "epel" }},
+		{true, func(c *packageRepositoryCheck) { c.Format = "yum"; c.URL = "http://mirror2.example.net/internal" }},
+		{false, func(c *packageRepositoryCheck) { c.Format = "yum"; c.Repository = "ignored" }},
+	} {
+		check := newPackageRepositoryFixtureCheck(dir)
+		test.setup(check)
+		check.Run(context.Background())
+
+		output := check.Output()
+		assert.Equal(test.passes, output.Passed, "%d: %+v", idx, output)
+		if test.passes {
+			assert.NoError(check.Error(), "%d", idx)
+		} else {
+			assert.Error(check.Error(), "%d", idx)
+		}
+	}
+}
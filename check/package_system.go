@@ -0,0 +1,84 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// defaultOSRelease is the os-release file used to detect the system's
+// distribution for the "package-*" checks.
+const defaultOSRelease = "/etc/os-release"
+
+// distroPackageManagers maps os-release IDs to the package managers
+// in packageCheckerRegistry.
+var distroPackageManagers = map[string]string{
+	"debian":    "dpkg",
+	"ubuntu":    "dpkg",
+	"rhel":      "yum",
+	"fedora":    "yum",
+	"centos":    "yum",
+	"rocky":     "yum",
+	"almalinux": "yum",
+	"amzn":      "yum",
+	"ol":        "yum",
+	"arch":      "pacman",
+	"alpine":    "apk",
+	"suse":      "zypper",
+	"opensuse":  "zypper",
+	"sles":      "zypper",
+	"macos":     "brew",
+}
+
+// detectSystemPackageManager reads the ID and ID_LIKE fields from an
+// os-release file, and returns the package manager for the first
+// known ID, along with all IDs in order. Systems without an
+// os-release file on macOS use brew.
+func detectSystemPackageManager(osRelease string) (string, []string, error) {
+	data, err := ioutil.ReadFile(osRelease)
+	if os.IsNotExist(err) && runtime.GOOS == "darwin" {
+		return "brew", []string{"macos"}, nil
+	} else if err != nil {
+		return "", nil, errors.Wrap(err, "problem reading os-release to detect package manager")
+	}
+
+	doc, err := parseKeyValueConfig(data)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "problem parsing '%s'", osRelease)
+	}
+
+	var ids []string
+	for _, key := range []string{"ID", "ID_LIKE"} {
+		if value, ok := resolveConfigPath(doc, key); ok {
+			ids = append(ids, strings.Fields(configValueString(value))...)
+		}
+	}
+
+	for _, id := range ids {
+		manager, ok := distroPackageManagers[id]
+		if !ok {
+			continue
+		}
+
+		if _, ok = packageCheckerRegistry[manager]; ok {
+			return manager, ids, nil
+		}
+	}
+
+	return "", ids, errors.Errorf("no known package manager for distribution [%s]", strings.Join(ids, ", "))
+}
+
+// systemPackageNameKey returns the first of the distribution IDs, or
+// the package manager's name, that has an entry in the name map.
+func systemPackageNameKey(ids []string, manager string, has func(string) bool) (string, bool) {
+	for _, key := range append(append([]string{}, ids...), manager) {
+		if has(key) {
+			return key, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,132 @@
+package check
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeOSRelease(t *testing.T, dir, name, content string) string {
+	fn := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(fn, []byte(content), 0644))
+	return fn
+}
+
+func TestDetectSystemPackageManager(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-os-release")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for content, expected := range map[string]string{
+		"ID=debian\nVERSION_ID=\"12\"\n":                    "dpkg",
+		"ID=ubuntu\nID_LIKE=debian\n":                       "dpkg",
+		"ID=linuxmint\nID_LIKE=\"ubuntu debian\"\n":         "dpkg",
+		"ID=\"rocky\"\nID_LIKE=\"rhel centos fedora\"\n":    "yum",
+		"ID=\"amzn\"\nID_LIKE=\"centos rhel fedora\"\n":     "yum",
+		"NAME=\"Arch Linux\"\nID=arch\n":                    "pacman",
+		"ID=\"ol\"\nID_LIKE=\"fedora\"\n# comment\n":        "yum",
+		"ID=\"opensuse-leap\"\nID_LIKE=\"suse opensuse\"\n": "zypper",
+		"ID=alpine\nVERSION_ID=3.19.1\n":                    "apk",
+		"ID=nixos\nID_LIKE=\"\"\n":                          "",
+	} {
+		manager, ids, err := detectSystemPackageManager(writeOSRelease(t, dir, "os-release", content))
+		if expected == "" {
+			assert.Error(err, content)
+			assert.Equal([]string{"nixos"}, ids)
+			continue
+		}
+
+		assert.NoError(err, content)
+		assert.Equal(expected, manager, content)
+		assert.NotEmpty(ids)
+	}
+
+	_, _, err = detectSystemPackageManager(filepath.Join(dir, "does-not-exist"))
+	assert.Error(err)
+}
+
+func TestSystemPackageNameKey(t *testing.T) {
+	assert := assert.New(t)
+	names := map[string]string{"debian": "libssl-dev", "rhel": "openssl-devel", "pacman": "openssl"}
+	has := func(k string) bool { _, ok := names[k]; return ok }
+
+	key, ok := systemPackageNameKey([]string{"ubuntu", "debian"}, "dpkg", has)
+	assert.True(ok)
+	assert.Equal("debian", key)
+
+	key, ok = systemPackageNameKey([]string{"rocky", "rhel", "centos"}, "yum", has)
+	assert.True(ok)
+	assert.Equal("rhel", key)
+
+	key, ok = systemPackageNameKey([]string{"manjaro"}, "pacman", has)
+	assert.True(ok)
+	assert.Equal("pacman", key)
+
+	_, ok = systemPackageNameKey([]string{"alpine"}, "apk", has)
+	assert.False(ok)
+}
+
+func TestSystemPackageChecksResolveNames(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-os-release")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ubuntu := writeOSRelease(t, dir, "ubuntu", "ID=ubuntu\nID_LIKE=debian\n")
+	rocky := writeOSRelease(t, dir, "rocky", "ID=rocky\nID_LIKE=\"rhel centos fedora\"\n")
+	names := map[string]string{"debian": "libssl-dev", "rhel": "openssl-devel"}
+
+	check := &packageInstalled{Base: NewBase("package-installed", 0), Names: names, osRelease: ubuntu}
+	assert.NoError(check.detectPackageManager())
+	assert.Equal("libssl-dev", check.Package)
+	assert.NotNil(check.checker)
+	assert.NotNil(check.versioner.version)
+	assert.Equal("dpkg", check.versioner.scheme)
+
+	check = &packageInstalled{Base: NewBase("package-installed", 0), Names: names, osRelease: rocky}
+	assert.NoError(check.detectPackageManager())
+	assert.Equal("openssl-devel", check.Package)
+	assert.Equal("rpm", check.versioner.scheme)
+
+	// the package field is the default
+	check = &packageInstalled{Base: NewBase("package-installed", 0), Package: "curl", osRelease: rocky}
+	assert.NoError(check.detectPackageManager())
+	assert.Equal("curl", check.Package)
+
+	// without a name for this distribution the check fails
+	check = &packageInstalled{Base: NewBase("package-installed", 0),
+		Names: map[string]string{"rhel": "openssl-devel"}, osRelease: ubuntu}
+	check.Run(context.Background())
+	assert.False(check.Output().Passed)
+	assert.Error(check.Error())
+
+	group := &packageGroup{
+		Base:         NewBase("package-group-all", 0),
+		Requirements: GroupRequirements{All: true, Name: "package-group-all"},
+		Names: map[string][]string{
+			"debian": {"libssl-dev", "zlib1g-dev"},
+			"rhel":   {"openssl-devel", "zlib-devel"},
+		},
+		osRelease: rocky,
+	}
+	assert.NoError(group.detectPackageManager())
+	assert.Equal([]string{"openssl-devel", "zlib-devel"}, group.Packages)
+	assert.NotNil(group.checker)
+
+	group = &packageGroup{
+		Base:         NewBase("package-group-all", 0),
+		Requirements: GroupRequirements{All: true, Name: "package-group-all"},
+		osRelease:    filepath.Join(dir, "does-not-exist"),
+	}
+	group.Run(context.Background())
+	assert.False(group.Output().Passed)
+	assert.Error(group.Error())
+}
@@ -28,14 +28,48 @@ func registerProgramChecks() {
 
 	registrar(compilerInterfaceFactoryTable())
 	registrar(goCompilerIterfaceFactoryTable())
-	registrar(scriptCompilerInterfaceFactoryTable())
 }
 
+// programOutputCheck runs a program, compiling it first for compiled
+// languages, and compares its output to the expected output, using the
+// match mode and options in output_match.go. The language selects C or
+// C++ for compilers that support both. The source and expected output
+// can be in files (source_file and output_file), relative to the
+// config file, instead.
 type programOutputCheck struct {
-	Source         string `bson:"source" json:"source" yaml:"source"`
-	ExpectedOutput string `bson:"output" json:"output" yaml:"output"`
-	*Base          `bson:"metadata" json:"metadata" yaml:"metadata"`
-	compiler       compiler
+	Source           string `bson:"source" json:"source" yaml:"source"`
+	SourceFile       string `bson:"source_file" json:"source_file" yaml:"source_file"`
+	Language         string `bson:"language" json:"language" yaml:"language"`
+	ExpectedOutput   string `bson:"output" json:"output" yaml:"output"`
+	OutputFile       string `bson:"output_file" json:"output_file" yaml:"output_file"`
+	Match            string `bson:"match" json:"match" yaml:"match"`
+	IgnoreWhitespace bool   `bson:"ignore_whitespace" json:"ignore_whitespace" yaml:"ignore_whitespace"`
+	IgnoreCase       bool   `bson:"ignore_case" json:"ignore_case" yaml:"ignore_case"`
+	*Base            `bson:"metadata" json:"metadata" yaml:"metadata"`
+	compiler         compiler
+	goldenOutput
+}
+
+func (c *programOutputCheck) matcher() outputMatcher {
+	return outputMatcher{
+		mode:             c.Match,
+		ignoreWhitespace: c.IgnoreWhitespace,
+		ignoreCase:       c.IgnoreCase,
+	}
+}
+
+// ReadArgumentFiles reads the source and expected output files.
+func (c *programOutputCheck) ReadArgumentFiles(dir string) ([]string, error) {
+	paths, err := readArgumentFiles(dir,
+		argumentFile{name: "source", file: c.SourceFile, value: &c.Source},
+		argumentFile{name: "output", file: c.OutputFile, value: &c.ExpectedOutput})
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem reading files for '%s'", c.ID())
+	}
+
+	c.setExpectedOutputFile(resolveArgumentFile(dir, c.OutputFile))
+
+	return paths, nil
 }
 
 func (c *programOutputCheck) Run(_ context.Context) {
@@ -54,9 +88,27 @@ func (c *programOutputCheck) Run(_ context.Context) {
 		return
 	}
 
-	c.ExpectedOutput = strings.Trim(c.ExpectedOutput, "\r\t\n ")
+	if _, ok := compileLanguages[c.Language]; c.Language != "" && !ok {
+		c.setState(false)
+		c.AddError(errors.Errorf("language '%s' is not supported for '%s'", c.Language, c.ID()))
+		return
+	}
 
-	output, err := c.compiler.CompileAndRun(c.Source)
+	if err := c.matcher().validate(c.ExpectedOutput); err != nil {
+		c.setState(false)
+		c.AddError(errors.Wrapf(err, "problem with expected output for '%s'", c.ID()))
+		return
+	}
+
+	workspace, err := newTestWorkspace()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+	defer c.cleanupTestWorkspace(workspace)
+
+	output, err := c.compiler.CompileAndRun(c.Source, compileOptions{workspace: workspace, language: c.Language})
 	if err != nil {
 		c.setState(false)
 		c.AddError(err)
@@ -64,15 +116,11 @@ func (c *programOutputCheck) Run(_ context.Context) {
 		return
 	}
 
-	if c.ExpectedOutput != output {
+	if msg, err := c.matcher().match(c.ExpectedOutput, output); err != nil {
 		c.setState(false)
-		c.AddError(errors.New("expected output does not match actual output"))
-		c.setMessage([]string{
-			"-------------------- EXPECTED --------------------",
-			c.ExpectedOutput,
-			"-------------------- ACTUAL --------------------",
-			output,
-		})
+		c.AddError(err)
+		c.setMessage(msg)
+		c.setActualOutput(c.matcher(), output)
 		return
 	}
 	c.setState(true)
@@ -0,0 +1,119 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "program-version"
+	registry.AddJobType(name, func() amboy.Job {
+		return &programVersion{
+			Base: NewBase(name, 0), // (name, version)
+		}
+	})
+}
+
+// defaultVersionPattern matches the first dotted version in a
+// program's output, including an optional pre-release suffix.
+const defaultVersionPattern = `(\d+(?:\.\d+)+(?:-[0-9A-Za-z.]+)?)`
+
+// programVersion runs a command, extracts a version from its output
+// using the first capture group of a regular expression, and asserts
+// that the version satisfies a constraint expression, e.g. ">=4.8 <6".
+// Versions are compared with the loose scheme unless another is
+// specified.
+type programVersion struct {
+	Command string `bson:"command" json:"command" yaml:"command"`
+	Pattern string `bson:"pattern" json:"pattern" yaml:"pattern"`
+	Version string `bson:"version" json:"version" yaml:"version"`
+	Scheme  string `bson:"scheme" json:"scheme" yaml:"scheme"`
+	*Base   `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+func (c *programVersion) validate() (*regexp.Regexp, *versionConstraints, error) {
+	if c.Command == "" {
+		return nil, nil, errors.Errorf("no command specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	if c.Pattern == "" {
+		c.Pattern = defaultVersionPattern
+		grip.Debugf("using default version pattern '%s' for '%s'", c.Pattern, c.ID())
+	}
+
+	pattern, err := regexp.Compile(c.Pattern)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid version pattern '%s'", c.Pattern)
+	}
+
+	if pattern.NumSubexp() < 1 {
+		return nil, nil, errors.Errorf("version pattern '%s' has no capture group", c.Pattern)
+	}
+
+	if c.Scheme == "" {
+		c.Scheme = "loose"
+	}
+
+	constraints, err := parseVersionConstraints(c.Scheme, c.Version)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "problem parsing version constraint for '%s'", c.ID())
+	}
+
+	return pattern, constraints, nil
+}
+
+func (c *programVersion) Run(ctx context.Context) {
+	c.startTask()
+	defer c.MarkComplete()
+
+	pattern, constraints, err := c.validate()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	out, err := exec.CommandContext(ctx, "sh", "-c", c.Command).CombinedOutput()
+	if err != nil {
+		c.setState(false)
+		c.setMessage(string(out))
+		c.AddError(errors.Wrapf(err, "command '%s' failed", c.Command))
+		return
+	}
+
+	match := pattern.FindSubmatch(out)
+	if match == nil {
+		c.setState(false)
+		c.setMessage(string(out))
+		c.AddError(errors.Errorf("output of '%s' does not match version pattern '%s'",
+			c.Command, c.Pattern))
+		return
+	}
+
+	actual := string(match[1])
+	ok, err := constraints.check(actual)
+	if err != nil {
+		c.setState(false)
+		c.setMessage(string(out))
+		c.AddError(errors.Wrapf(err, "problem parsing version from output of '%s'", c.Command))
+		return
+	}
+
+	if !ok {
+		c.setState(false)
+		c.setMessage(string(out))
+		c.AddError(errors.Errorf("version '%s' of '%s' does not satisfy '%s'",
+			actual, c.Command, c.Version))
+		return
+	}
+
+	c.setMessage(fmt.Sprintf("version '%s' satisfies '%s'", actual, c.Version))
+	c.setState(true)
+}
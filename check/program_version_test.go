@@ -0,0 +1,73 @@
+package check
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgramVersionValidation(t *testing.T) {
+	assert := assert.New(t)
+	check := &programVersion{Base: NewBase("program-version", 0)}
+
+	_, _, err := check.validate()
+	assert.Error(err)
+
+	check.Command = "echo 1.0"
+	_, _, err = check.validate()
+	assert.Error(err, "missing version range")
+	assert.Equal(defaultVersionPattern, check.Pattern)
+
+	check.Version = ">=1"
+	_, _, err = check.validate()
+	assert.NoError(err)
+
+	check.Pattern = `\d+\.\d+`
+	_, _, err = check.validate()
+	assert.Error(err, "no capture group")
+
+	check.Pattern = `(\d+`
+	_, _, err = check.validate()
+	assert.Error(err, "invalid regex")
+}
+
+func TestProgramVersionCheck(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		command string
+		pattern string
+		version string
+		passes  bool
+	}{
+		{"echo 'gcc (GCC) 4.8.5 20150623 (Red Hat 4.8.5-44)'", "", ">=4.8 <6", true},
+		{"echo 'gcc (GCC) 4.8.5 20150623 (Red Hat 4.8.5-44)'", "", ">=5", false},
+		{"echo 'Python 2.7'", "", "<3", true},
+		{"echo 'db version v8.0.1-rc1'", "", ">=8.0.1", false},
+		{"echo 'db version v8.0.1-rc1'", "", ">=8.0.0", true},
+		{"echo 'git version 2.39.2'", `git version (\S+)`, ">=2.30", true},
+		{"echo 'no version here'", "", ">=1", false},
+		{"echo 'version foo'", `version (\S+)`, ">=1", false},
+		{"echo broken; exit 1", "", ">=1", false},
+	}
+
+	for _, test := range cases {
+		check := &programVersion{
+			Base:    NewBase("program-version", 0),
+			Command: test.command,
+			Pattern: test.pattern,
+			Version: test.version,
+		}
+		check.Run(ctx)
+
+		output := check.Output()
+		assert.True(output.Completed)
+		assert.Equal(test.passes, output.Passed, "%s: %s", test.command, test.version)
+		if !test.passes {
+			assert.Error(check.Error())
+			assert.NotEqual("", output.Message, "failures should report the raw output")
+		}
+	}
+}
@@ -6,7 +6,6 @@ import (
 	"os/exec"
 	"strings"
 
-	"github.com/blang/semver"
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/registry"
 	"github.com/pkg/errors"
@@ -24,6 +23,12 @@ func init() {
 	})
 }
 
+// pythonModuleVersion asserts the version of a python module. The
+// version may be a constraint expression (e.g. ">=1.2,<2"), or a
+// single version compared using the relationship and, optionally,
+// combined with a second minimum version and relationship. Versions
+// are compared as PEP 440 versions unless another scheme is
+// specified.
 type pythonModuleVersion struct {
 	Module            string `bson:"module" json:"module" yaml:"module"`
 	Statement         string `bson:"statement" json:"statement" yaml:"statement"`
@@ -32,6 +37,7 @@ type pythonModuleVersion struct {
 	MinRelationship   string `bson:"minRelationship" json:"minRelationship" yaml:"minRelationship"`
 	PythonInterpreter string `bson:"python" json:"python" yaml:"python"`
 	Relationship      string `bson:"relationship" json:"relationship" yaml:"relationship"`
+	Scheme            string `bson:"scheme" json:"scheme" yaml:"scheme"`
 	*Base             `bson:"metadata" json:"metadata" yaml:"metadata"`
 }
 
@@ -72,9 +78,37 @@ func (c *pythonModuleVersion) validate() error {
 		grip.Debugln("relationship for '%s' check set to '%s'", c.ID(), c.MinRelationship)
 	}
 
+	if c.Scheme == "" {
+		c.Scheme = "pep440"
+	}
+
 	return nil
 }
 
+// constraintExpression combines the version and relationship fields
+// into a single constraint expression. Versions that are already
+// expressions are used as is.
+func (c *pythonModuleVersion) constraintExpression() (string, error) {
+	expr := c.Version
+	if !strings.ContainsAny(c.Version, "<>=!~,|* ") {
+		op, ok := versionRelationships[c.Relationship]
+		if !ok {
+			return "", errors.Errorf("relationship '%s' is not valid", c.Relationship)
+		}
+		expr = op + c.Version
+	}
+
+	if c.MinVersion != "" {
+		op, ok := versionRelationships[c.MinRelationship]
+		if !ok {
+			return "", errors.Errorf("relationship '%s' is not valid", c.MinRelationship)
+		}
+		expr += "," + op + c.MinVersion
+	}
+
+	return expr, nil
+}
+
 func (c *pythonModuleVersion) Run(_ context.Context) {
 	c.startTask()
 
@@ -86,23 +120,19 @@ func (c *pythonModuleVersion) Run(_ context.Context) {
 		return
 	}
 
-	expected, err := semver.Parse(c.Version)
+	expr, err := c.constraintExpression()
 	if err != nil {
 		c.setState(false)
 		c.AddError(err)
-		c.setMessage(fmt.Sprintf("could not parse expected version '%s'", c.Version))
 		return
 	}
 
-	var minExpected semver.Version
-	if c.MinVersion != "" {
-		minExpected, err = semver.Parse(c.MinVersion)
-		if err != nil {
-			c.setState(false)
-			c.AddError(err)
-			c.setMessage(fmt.Sprintf("could not parse expected version '%s'", c.Version))
-			return
-		}
+	constraints, err := parseVersionConstraints(c.Scheme, expr)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		c.setMessage(fmt.Sprintf("could not parse expected version '%s'", expr))
+		return
 	}
 
 	cmdArgs := []string{
@@ -120,7 +150,7 @@ func (c *pythonModuleVersion) Run(_ context.Context) {
 		return
 	}
 
-	parsed, err := semver.Parse(version)
+	result, err := constraints.check(version)
 	if err != nil {
 		c.setState(false)
 		c.AddError(err)
@@ -129,30 +159,9 @@ func (c *pythonModuleVersion) Run(_ context.Context) {
 		return
 	}
 
-	var result bool
-	result, err = compareVersions(c.Relationship, parsed, expected)
-	if err != nil {
-		// this should be unreachable, because the earlier
-		// validate will have caught it.
-		c.setState(false)
-		c.AddError(err)
-		return
-	}
-
-	if c.MinVersion != "" {
-		gteMin, err := compareVersions(c.MinRelationship, parsed, minExpected)
-		if err != nil {
-			c.setState(false)
-			c.AddError(err)
-			return
-		}
-
-		result = result && gteMin
-	}
-
 	if !result {
 		c.setState(false)
-		msg := fmt.Sprintln(parsed, c.Relationship, expected)
+		msg := fmt.Sprintf("version '%s' of module '%s' does not satisfy '%s'", version, c.Module, expr)
 		c.AddError(errors.Errorf("check failed: %s", msg))
 		c.setMessage(msg)
 		return
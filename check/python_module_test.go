@@ -130,3 +130,49 @@ func (s *PythonModuleSuite) TestReturnsErrorWhenExpectedValueDoesNotPassComparis
 	s.Error(s.check.Error())
 	s.False(s.check.Output().Passed)
 }
+
+func (s *PythonModuleSuite) TestNonSemverVersionsArePEP440() {
+	s.check.Statement = "'2.7'"
+	s.check.Version = "2.7.0"
+
+	s.check.Run(context.Background())
+	s.NoError(s.check.Error())
+	s.True(s.check.Output().Passed)
+}
+
+func (s *PythonModuleSuite) TestVersionConstraintExpressions() {
+	s.check.Statement = "'1.5rc1'"
+
+	for expr, passes := range map[string]bool{
+		">=1.2,<2 || ==3.0.*": true,
+		">=1.5":               false,
+		"==1.5.*":             true,
+		"~=1.4":               true,
+	} {
+		s.SetupTest()
+		s.check.Statement = "'1.5rc1'"
+		s.check.Version = expr
+		s.check.Run(context.Background())
+		s.Equal(passes, s.check.Output().Passed, expr)
+	}
+}
+
+func (s *PythonModuleSuite) TestMinVersionCombinesWithRelationship() {
+	s.check.Statement = "'1.5'"
+	s.check.Relationship = ""
+	s.check.Version = "2.0"
+	s.check.MinVersion = "1.0"
+	s.check.Scheme = "loose"
+
+	s.check.Run(context.Background())
+	s.NoError(s.check.Error())
+	s.True(s.check.Output().Passed)
+
+	s.SetupTest()
+	s.check.Statement = "'0.5'"
+	s.check.Relationship = ""
+	s.check.Version = "2.0"
+	s.check.MinVersion = "1.0"
+	s.check.Run(context.Background())
+	s.False(s.check.Output().Passed)
+}
@@ -0,0 +1,220 @@
+package check
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/pkg/errors"
+)
+
+// these are the modes of script checks: "run-script" checks pass when
+// the exit code (and output, if specified) match, "run-script-succeeds"
+// checks pass when the script exits 0, and "run-script-output" checks
+// pass when the script exits 0 with the expected output.
+const (
+	scriptRun      = "run"
+	scriptSucceeds = "succeeds"
+	scriptOutput   = "output"
+)
+
+// this would be an init function but is simply called from the init()
+// in init.go to avoid ordering effects.
+func registerScriptChecks() {
+	scriptCheckFactoryFactory := func(name, mode string, script compileScript) func() amboy.Job {
+		return func() amboy.Job {
+			return &scriptCheck{
+				Base:   NewBase(name, 0),
+				mode:   mode,
+				script: script,
+			}
+		}
+	}
+
+	registry.AddJobType("run-script", scriptCheckFactoryFactory("run-script", scriptRun, compileScript{}))
+	registry.AddJobType("run-script-succeeds", scriptCheckFactoryFactory("run-script-succeeds", scriptSucceeds, compileScript{}))
+	registry.AddJobType("run-script-output", scriptCheckFactoryFactory("run-script-output", scriptOutput, compileScript{}))
+
+	// the checks for specific interpreters are aliases for the
+	// generic checks, with the interpreter set.
+	for name, factory := range scriptInterpreterTable() {
+		script := factory()
+		registry.AddJobType(name, scriptCheckFactoryFactory(name, scriptOutput, script))
+
+		if strings.Contains(name, "-script") {
+			// Take a check like "run-bash-script", and add an additional check called
+			// "run-bash-script-succeeds" that checks only that the return code is 0.
+			name = strings.Replace(name, "-script", "-script-succeeds", 1)
+			registry.AddJobType(name, scriptCheckFactoryFactory(name, scriptSucceeds, script))
+		}
+	}
+}
+
+// scriptCheck runs a script with an interpreter (e.g. "/bin/bash" or
+// "python3"), and optional arguments for the interpreter that go
+// before the script. The script's extension defaults to one that
+// matches the interpreter. Scripts run in a temporary directory,
+// unless the working directory is specified, with the environment
+// variables added to greenbay's environment.
+//
+// The output is matched using the match mode and options in
+// output_match.go. The source and expected output can instead be in
+// files (source_file and output_file), relative to the config file.
+//
+// The checks for specific interpreters, like "run-bash-script", use
+// their interpreter unless the check specifies another.
+type scriptCheck struct {
+	Source           string            `bson:"source" json:"source" yaml:"source"`
+	SourceFile       string            `bson:"source_file" json:"source_file" yaml:"source_file"`
+	Interpreter      string            `bson:"interpreter" json:"interpreter" yaml:"interpreter"`
+	InterpreterArgs  []string          `bson:"interpreter_args" json:"interpreter_args" yaml:"interpreter_args"`
+	Extension        string            `bson:"extension" json:"extension" yaml:"extension"`
+	Env              map[string]string `bson:"env" json:"env" yaml:"env"`
+	WorkingDirectory string            `bson:"working_directory" json:"working_directory" yaml:"working_directory"`
+	ExpectedOutput   string            `bson:"output" json:"output" yaml:"output"`
+	OutputFile       string            `bson:"output_file" json:"output_file" yaml:"output_file"`
+	Match            string            `bson:"match" json:"match" yaml:"match"`
+	IgnoreWhitespace bool              `bson:"ignore_whitespace" json:"ignore_whitespace" yaml:"ignore_whitespace"`
+	IgnoreCase       bool              `bson:"ignore_case" json:"ignore_case" yaml:"ignore_case"`
+	ExitCode         int               `bson:"exit_code" json:"exit_code" yaml:"exit_code"`
+	*Base            `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	mode   string
+	script compileScript
+	goldenOutput
+}
+
+func (c *scriptCheck) matcher() outputMatcher {
+	return outputMatcher{
+		mode:             c.Match,
+		ignoreWhitespace: c.IgnoreWhitespace,
+		ignoreCase:       c.IgnoreCase,
+	}
+}
+
+// ReadArgumentFiles reads the source and expected output files.
+func (c *scriptCheck) ReadArgumentFiles(dir string) ([]string, error) {
+	paths, err := readArgumentFiles(dir,
+		argumentFile{name: "source", file: c.SourceFile, value: &c.Source},
+		argumentFile{name: "output", file: c.OutputFile, value: &c.ExpectedOutput})
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem reading files for '%s'", c.ID())
+	}
+
+	c.setExpectedOutputFile(resolveArgumentFile(dir, c.OutputFile))
+
+	return paths, nil
+}
+
+func (c *scriptCheck) validate() error {
+	if c.Interpreter != "" {
+		c.script.bin = c.Interpreter
+	}
+
+	if len(c.InterpreterArgs) > 0 {
+		c.script.args = c.InterpreterArgs
+	}
+
+	if c.Extension != "" {
+		c.script.ext = c.Extension
+	}
+
+	if err := c.script.Validate(); err != nil {
+		return errors.Wrapf(err, "no interpreter for '%s' (%s)", c.ID(), c.Name())
+	}
+
+	if c.mode == scriptOutput && c.ExpectedOutput == "" {
+		return errors.Errorf("expected output for check '%s' can't be empty", c.ID())
+	}
+
+	if c.ExpectedOutput != "" {
+		if err := c.matcher().validate(c.ExpectedOutput); err != nil {
+			return errors.Wrapf(err, "problem with expected output for '%s'", c.ID())
+		}
+	}
+
+	if c.WorkingDirectory != "" {
+		stat, err := os.Stat(c.WorkingDirectory)
+		if err != nil {
+			return errors.Wrapf(err, "problem with working directory for '%s'", c.ID())
+		}
+
+		if !stat.IsDir() {
+			return errors.Errorf("working directory '%s' for '%s' is not a directory",
+				c.WorkingDirectory, c.ID())
+		}
+	}
+
+	return nil
+}
+
+func (c *scriptCheck) Run(_ context.Context) {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	workspace, err := newTestWorkspace()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+	defer c.cleanupTestWorkspace(workspace)
+
+	output, err := c.script.CompileAndRun(c.Source, compileOptions{
+		workspace: workspace,
+		env:       c.Env,
+		directory: c.WorkingDirectory,
+	})
+
+	code := 0
+	if exitErr, ok := errors.Cause(err).(*programExitError); ok {
+		code = exitErr.code
+		err = nil
+	}
+
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		c.setMessage(output)
+		return
+	}
+
+	expectedCode := c.ExitCode
+	if c.mode != scriptRun {
+		expectedCode = 0
+	}
+
+	if code != expectedCode {
+		c.setState(false)
+		if c.mode == scriptSucceeds {
+			c.AddError(errors.New("program did not exit 0"))
+		} else {
+			c.AddError(errors.Errorf("script exited with code %d, expected %d", code, expectedCode))
+		}
+		c.setMessage(output)
+		return
+	}
+
+	if c.mode == scriptSucceeds || c.ExpectedOutput == "" {
+		c.setState(true)
+		return
+	}
+
+	if msg, err := c.matcher().match(c.ExpectedOutput, output); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		c.setMessage(msg)
+		c.setActualOutput(c.matcher(), output)
+		return
+	}
+
+	c.setState(true)
+}
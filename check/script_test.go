@@ -0,0 +1,150 @@
+package check
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/mongodb/amboy/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptExtensionsMatchInterpreters(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("py", scriptExtension("python"))
+	assert.Equal("py", scriptExtension("/usr/bin/python3.11"))
+	assert.Equal("py", scriptExtension("/opt/mongodbtoolchain/v4/bin/python3"))
+	assert.Equal("sh", scriptExtension("/bin/bash"))
+	assert.Equal("sh", scriptExtension("sh"))
+	assert.Equal("rb", scriptExtension("ruby"))
+	assert.Equal("ps1", scriptExtension("/usr/bin/pwsh"))
+	assert.Equal("", scriptExtension("/usr/bin/unknown-interpreter"))
+
+	assert.Equal("sh", compileScript{bin: "/bin/bash"}.extension())
+	assert.Equal("tcl", compileScript{bin: "/bin/bash", ext: ".tcl"}.extension())
+	assert.Equal("", compileScript{bin: "tclsh"}.extension())
+}
+
+func TestScriptAliasesAreRegistered(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, name := range []string{"run-script", "run-script-succeeds", "run-script-output",
+		"run-bash-script", "run-bash-script-succeeds", "run-program-system-python3"} {
+		factory, err := registry.GetJobFactory(name)
+		if !assert.NoError(err, name) {
+			continue
+		}
+
+		check, ok := factory().(*scriptCheck)
+		if assert.True(ok, name) {
+			assert.Equal(name, check.Name())
+		}
+	}
+
+	factory, err := registry.GetJobFactory("run-bash-script")
+	require.NoError(t, err)
+	check := factory().(*scriptCheck)
+	assert.Equal(scriptOutput, check.mode)
+	assert.Equal("/bin/bash", check.script.bin)
+
+	factory, err = registry.GetJobFactory("run-bash-script-succeeds")
+	require.NoError(t, err)
+	check = factory().(*scriptCheck)
+	assert.Equal(scriptSucceeds, check.mode)
+	assert.Equal("/bin/bash", check.script.bin)
+
+	factory, err = registry.GetJobFactory("run-script")
+	require.NoError(t, err)
+	check = factory().(*scriptCheck)
+	assert.Equal(scriptRun, check.mode)
+	assert.Equal("", check.script.bin)
+}
+
+func TestScriptChecks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires sh")
+	}
+
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-script-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+
+	run := func(check *scriptCheck, mode string) *scriptCheck {
+		check.Base = NewBase("run-script", 0)
+		check.mode = mode
+		check.Run(context.Background())
+		return check
+	}
+
+	// the interpreter is required
+	check := run(&scriptCheck{Source: "echo hi", ExpectedOutput: "hi"}, scriptOutput)
+	assert.False(check.getState())
+	assert.Error(check.Error())
+
+	// output mode checks output
+	check = run(&scriptCheck{Interpreter: "sh", Source: "echo hi", ExpectedOutput: "hi\n"}, scriptOutput)
+	assert.True(check.getState(), "%+v", check.Error())
+
+	check = run(&scriptCheck{Interpreter: "sh", Source: "echo hello", ExpectedOutput: "hi"}, scriptOutput)
+	assert.False(check.getState())
+
+	// output mode requires output
+	check = run(&scriptCheck{Interpreter: "sh", Source: "echo hi"}, scriptOutput)
+	assert.False(check.getState())
+
+	// succeeds mode only checks the exit code
+	check = run(&scriptCheck{Interpreter: "sh", Source: "echo hi"}, scriptSucceeds)
+	assert.True(check.getState(), "%+v", check.Error())
+
+	check = run(&scriptCheck{Interpreter: "sh", Source: "exit 3"}, scriptSucceeds)
+	assert.False(check.getState())
+
+	// run mode checks the exit code and, optionally, the output
+	check = run(&scriptCheck{Interpreter: "sh", Source: "echo hi; exit 3", ExitCode: 3}, scriptRun)
+	assert.True(check.getState(), "%+v", check.Error())
+
+	check = run(&scriptCheck{Interpreter: "sh", Source: "echo hi; exit 3", ExitCode: 3, ExpectedOutput: "hi"}, scriptRun)
+	assert.True(check.getState(), "%+v", check.Error())
+
+	check = run(&scriptCheck{Interpreter: "sh", Source: "echo hi; exit 3", ExitCode: 3, ExpectedOutput: "hello"}, scriptRun)
+	assert.False(check.getState())
+
+	check = run(&scriptCheck{Interpreter: "sh", Source: "exit 0", ExitCode: 3}, scriptRun)
+	assert.False(check.getState())
+
+	// the environment, working directory and interpreter arguments
+	// are passed to the script
+	check = run(&scriptCheck{
+		Interpreter:      "sh",
+		InterpreterArgs:  []string{"-e"},
+		Source:           "echo $GREENBAY_TEST_VALUE; pwd; false; echo unreachable",
+		Env:              map[string]string{"GREENBAY_TEST_VALUE": "forty-two"},
+		WorkingDirectory: dir,
+		ExitCode:         1,
+		ExpectedOutput:   "forty-two\n" + dir,
+	}, scriptRun)
+	assert.True(check.getState(), "%+v", check.Error())
+
+	check = run(&scriptCheck{
+		Interpreter:      "sh",
+		Source:           "pwd",
+		WorkingDirectory: filepath.Join(dir, "does-not-exist"),
+	}, scriptSucceeds)
+	assert.False(check.getState())
+
+	// the extension is based on the interpreter unless specified
+	check = run(&scriptCheck{Interpreter: "/bin/sh", Source: "basename $0", ExpectedOutput: "test.sh"}, scriptOutput)
+	assert.True(check.getState(), "%+v", check.Error())
+
+	check = run(&scriptCheck{Interpreter: "/bin/sh", Extension: ".bash", Source: "basename $0", ExpectedOutput: "test.bash"}, scriptOutput)
+	assert.True(check.getState(), "%+v", check.Error())
+}
@@ -0,0 +1,137 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	securityModuleFactoryFactory := func(name string, mod securityModule) func() amboy.Job {
+		return func() amboy.Job {
+			return &securityModuleCheck{
+				Base:   NewBase(name, 0),
+				module: mod,
+				root:   "/",
+			}
+		}
+	}
+
+	for name, mod := range securityModuleTable() {
+		registry.AddJobType(name, securityModuleFactoryFactory(name, mod))
+	}
+}
+
+// securityModule reports the current mode of a linux security module
+// given a root directory, which is "/" except in tests.
+type securityModule struct {
+	modes   []string
+	current func(root string) (string, error)
+}
+
+func securityModuleTable() map[string]securityModule {
+	return map[string]securityModule{
+		"selinux-mode": {
+			modes:   []string{"enforcing", "permissive", "disabled"},
+			current: selinuxMode,
+		},
+		"apparmor-mode": {
+			modes:   []string{"enabled", "disabled"},
+			current: apparmorMode,
+		},
+	}
+}
+
+func readSysfsValue(root, path string) (string, bool, error) {
+	data, err := ioutil.ReadFile(filepath.Join(root, path))
+	if os.IsNotExist(err) {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, errors.Wrapf(err, "problem reading '%s'", path)
+	}
+
+	return strings.TrimSpace(string(data)), true, nil
+}
+
+func selinuxMode(root string) (string, error) {
+	value, exists, err := readSysfsValue(root, "sys/fs/selinux/enforce")
+	if err != nil || !exists {
+		return "disabled", err
+	}
+
+	switch value {
+	case "1":
+		return "enforcing", nil
+	case "0":
+		return "permissive", nil
+	default:
+		return "", errors.Errorf("unexpected selinux enforce value '%s'", value)
+	}
+}
+
+func apparmorMode(root string) (string, error) {
+	value, exists, err := readSysfsValue(root, "sys/module/apparmor/parameters/enabled")
+	if err != nil || !exists {
+		return "disabled", err
+	}
+
+	if strings.HasPrefix(value, "Y") {
+		return "enabled", nil
+	}
+
+	return "disabled", nil
+}
+
+type securityModuleCheck struct {
+	Mode  string `bson:"mode" json:"mode" yaml:"mode"`
+	*Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	module securityModule
+	root   string
+}
+
+func (c *securityModuleCheck) validate() error {
+	for _, mode := range c.module.modes {
+		if c.Mode == mode {
+			return nil
+		}
+	}
+
+	return errors.Errorf("mode '%s' is not valid for %s, use one of [%s]",
+		c.Mode, c.Name(), strings.Join(c.module.modes, ", "))
+}
+
+func (c *securityModuleCheck) Run(_ context.Context) {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	mode, err := c.module.current(c.root)
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	if mode != c.Mode {
+		msg := fmt.Sprintf("%s is '%s', expected '%s'", c.Name(), mode, c.Mode)
+		c.setState(false)
+		c.setMessage(msg)
+		c.AddError(errors.Errorf("check failed: %s", msg))
+		return
+	}
+
+	c.setState(true)
+}
@@ -0,0 +1,97 @@
+package check
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSysfsFixture(t *testing.T, root, path, value string) {
+	fn := filepath.Join(root, path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(fn), 0755))
+	require.NoError(t, ioutil.WriteFile(fn, []byte(value), 0644))
+}
+
+func TestSecurityModuleModes(t *testing.T) {
+	assert := assert.New(t)
+
+	root, err := ioutil.TempDir("", "greenbay-sysfs-")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	mode, err := selinuxMode(root)
+	assert.NoError(err)
+	assert.Equal("disabled", mode)
+
+	mode, err = apparmorMode(root)
+	assert.NoError(err)
+	assert.Equal("disabled", mode)
+
+	writeSysfsFixture(t, root, "sys/fs/selinux/enforce", "1")
+	mode, err = selinuxMode(root)
+	assert.NoError(err)
+	assert.Equal("enforcing", mode)
+
+	writeSysfsFixture(t, root, "sys/fs/selinux/enforce", "0\n")
+	mode, err = selinuxMode(root)
+	assert.NoError(err)
+	assert.Equal("permissive", mode)
+
+	writeSysfsFixture(t, root, "sys/fs/selinux/enforce", "2")
+	_, err = selinuxMode(root)
+	assert.Error(err)
+
+	writeSysfsFixture(t, root, "sys/module/apparmor/parameters/enabled", "Y\n")
+	mode, err = apparmorMode(root)
+	assert.NoError(err)
+	assert.Equal("enabled", mode)
+
+	writeSysfsFixture(t, root, "sys/module/apparmor/parameters/enabled", "N\n")
+	mode, err = apparmorMode(root)
+	assert.NoError(err)
+	assert.Equal("disabled", mode)
+}
+
+func TestSecurityModuleCheck(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	table := securityModuleTable()
+
+	root, err := ioutil.TempDir("", "greenbay-sysfs-")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+	writeSysfsFixture(t, root, "sys/fs/selinux/enforce", "1")
+
+	cases := []struct {
+		name     string
+		mode     string
+		expected bool
+	}{
+		{"selinux-mode", "", false},
+		{"selinux-mode", "enabled", false},
+		{"selinux-mode", "enforcing", true},
+		{"selinux-mode", "permissive", false},
+		{"apparmor-mode", "enforcing", false},
+		{"apparmor-mode", "disabled", true},
+		{"apparmor-mode", "enabled", false},
+	}
+
+	for _, c := range cases {
+		check := &securityModuleCheck{
+			Mode:   c.mode,
+			Base:   NewBase(c.name, 0),
+			module: table[c.name],
+			root:   root,
+		}
+		check.Run(ctx)
+		output := check.Output()
+		assert.True(output.Completed)
+		assert.Equal(c.expected, output.Passed, "%s=%s: %s", c.name, c.mode, output.Error)
+		assert.Equal(!c.expected, check.Error() != nil)
+	}
+}
@@ -0,0 +1,100 @@
+package check
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// splitShellWords splits a string into words the way a POSIX shell
+// would, without performing any expansion: words are separated by
+// unquoted whitespace, single quotes preserve everything literally,
+// and backslashes escape the next character outside of quotes and
+// '"', '\', '$' and '`' inside double quotes.
+func splitShellWords(line string) ([]string, error) {
+	var (
+		out     []string
+		word    bytes.Buffer
+		inWord  bool
+		escaped bool
+		quote   rune
+	)
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\"\\$`\n", r) {
+				word.WriteRune('\\')
+			}
+			if r != '\n' {
+				word.WriteRune(r)
+				inWord = true
+			}
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				out = append(out, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if escaped {
+		return nil, errors.Errorf("trailing backslash in '%s'", line)
+	}
+
+	if quote != 0 {
+		return nil, errors.Errorf("unterminated %c quote in '%s'", quote, line)
+	}
+
+	if inWord {
+		out = append(out, word.String())
+	}
+
+	return out, nil
+}
+
+// runFlagsCommand runs a command, like "pkg-config --cflags openssl",
+// and splits its output into compiler or linker flags.
+func runFlagsCommand(command string) ([]string, error) {
+	args, err := splitShellWords(command)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem parsing flags command")
+	}
+
+	if len(args) == 0 {
+		return nil, errors.New("flags command is empty")
+	}
+
+	output, err := exec.Command(args[0], args[1:]...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, errors.Wrapf(err, "problem running '%s': %s", command,
+				strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, errors.Wrapf(err, "problem running '%s'", command)
+	}
+
+	flags, err := splitShellWords(string(output))
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem parsing output of '%s'", command)
+	}
+
+	return flags, nil
+}
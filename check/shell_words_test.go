@@ -0,0 +1,82 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	assert := assert.New(t)
+
+	for input, expected := range map[string][]string{
+		"":                                      nil,
+		"   \t\n":                               nil,
+		"-I/usr/include/openssl -lssl -lcrypto": {"-I/usr/include/openssl", "-lssl", "-lcrypto"},
+		"  -lssl\n":                             {"-lssl"},
+		`-DNAME="hello world" -I'/opt/my dir'`:  {"-DNAME=hello world", "-I/opt/my dir"},
+		`-DA=\"b\" c\ d`:                        {`-DA="b"`, "c d"},
+		`"a\"b" "c\d" 'e\f'`:                    {`a"b`, `c\d`, `e\f`},
+		`'' ""`:                                 {"", ""},
+		"-la \\\n -lb":                          {"-la", "-lb"},
+		`x"y"'z'`:                               {"xyz"},
+	} {
+		words, err := splitShellWords(input)
+		assert.NoError(err, input)
+		assert.Equal(expected, words, input)
+	}
+
+	for _, input := range []string{`"unterminated`, `'unterminated`, `trailing\`} {
+		_, err := splitShellWords(input)
+		assert.Error(err, input)
+	}
+}
+
+func writeFakePkgConfig(t *testing.T, dir string) string {
+	script := filepath.Join(dir, "pkg-config")
+	require.NoError(t, ioutil.WriteFile(script, []byte(`#!/bin/sh
+case "$1" in
+  --cflags) echo "-I$(dirname "$0")/include -DGREETING='\"hello from pkg-config\"'" ;;
+  --libs) echo "-lm" ;;
+  *) echo "unknown package" >&2; exit 1 ;;
+esac
+`), 0755))
+
+	return script
+}
+
+func TestRunFlagsCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake pkg-config requires a POSIX shell")
+	}
+
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "greenbay-pkg-config")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	script := writeFakePkgConfig(t, dir)
+
+	flags, err := runFlagsCommand(script + " --cflags openssl")
+	assert.NoError(err)
+	assert.Equal([]string{"-I" + dir + "/include", `-DGREETING="hello from pkg-config"`}, flags)
+
+	flags, err = runFlagsCommand(script + " --libs openssl")
+	assert.NoError(err)
+	assert.Equal([]string{"-lm"}, flags)
+
+	_, err = runFlagsCommand(script + " --version")
+	assert.Error(err)
+	assert.Contains(err.Error(), "unknown package")
+
+	_, err = runFlagsCommand("")
+	assert.Error(err)
+
+	_, err = runFlagsCommand(filepath.Join(dir, "does-not-exist"))
+	assert.Error(err)
+}
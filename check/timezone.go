@@ -0,0 +1,110 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "system-timezone"
+	registry.AddJobType(name, func() amboy.Job {
+		return &systemTimezone{
+			Base:      NewBase(name, 0), // (name, version)
+			localtime: "/etc/localtime",
+			timezone:  "/etc/timezone",
+		}
+	})
+}
+
+// systemTimezone asserts the configured timezone, taken from the TZ
+// environment variable if set, and otherwise from the target of the
+// /etc/localtime symlink (falling back to /etc/timezone).
+type systemTimezone struct {
+	Timezone string `bson:"timezone" json:"timezone" yaml:"timezone"`
+	IgnoreTZ bool   `bson:"ignore_tz_env" json:"ignore_tz_env" yaml:"ignore_tz_env"`
+	*Base    `bson:"metadata" json:"metadata" yaml:"metadata"`
+
+	localtime string
+	timezone  string
+}
+
+// utcAliases lists the zoneinfo names that are all equivalent to UTC.
+var utcAliases = map[string]bool{
+	"UTC":           true,
+	"UCT":           true,
+	"Universal":     true,
+	"Zulu":          true,
+	"Etc/UTC":       true,
+	"Etc/UCT":       true,
+	"Etc/Universal": true,
+	"Etc/Zulu":      true,
+	"Etc/GMT":       true,
+	"GMT":           true,
+}
+
+func normalizeTimezone(tz string) string {
+	tz = strings.TrimPrefix(strings.TrimSpace(tz), ":")
+	if idx := strings.Index(tz, "zoneinfo/"); idx >= 0 {
+		tz = tz[idx+len("zoneinfo/"):]
+	}
+
+	if utcAliases[tz] {
+		return "UTC"
+	}
+
+	return tz
+}
+
+func (c *systemTimezone) Run(_ context.Context) {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if c.Timezone == "" {
+		c.setState(false)
+		c.AddError(errors.Errorf("no timezone specified for '%s' (%s) check", c.ID(), c.Name()))
+		return
+	}
+
+	actual, source, err := c.currentTimezone()
+	if err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	if normalizeTimezone(actual) != normalizeTimezone(c.Timezone) {
+		c.setState(false)
+		msg := fmt.Sprintf("timezone is '%s' (from %s), expected '%s'", actual, source, c.Timezone)
+		c.setMessage(msg)
+		c.AddError(errors.Errorf("check failed: %s", msg))
+		return
+	}
+
+	c.setState(true)
+}
+
+func (c *systemTimezone) currentTimezone() (string, string, error) {
+	if tz := os.Getenv("TZ"); tz != "" && !c.IgnoreTZ {
+		return tz, "TZ", nil
+	}
+
+	target, err := os.Readlink(c.localtime)
+	if err == nil {
+		return normalizeTimezone(target), c.localtime, nil
+	}
+
+	data, err := ioutil.ReadFile(c.timezone)
+	if err == nil {
+		return strings.TrimSpace(string(data)), c.timezone, nil
+	}
+
+	return "", "", errors.Errorf("could not determine timezone from TZ, '%s', or '%s'",
+		c.localtime, c.timezone)
+}
@@ -0,0 +1,79 @@
+package check
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTimezone(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("UTC", normalizeTimezone("Etc/UTC"))
+	assert.Equal("UTC", normalizeTimezone("/usr/share/zoneinfo/Etc/UTC"))
+	assert.Equal("UTC", normalizeTimezone(":UTC"))
+	assert.Equal("America/New_York", normalizeTimezone("../usr/share/zoneinfo/America/New_York"))
+	assert.Equal("America/New_York", normalizeTimezone("America/New_York\n"))
+}
+
+func TestSystemTimezoneCheck(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "greenbay-tz-")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	localtime := filepath.Join(dir, "localtime")
+	timezone := filepath.Join(dir, "timezone")
+	require.NoError(os.Symlink("/usr/share/zoneinfo/Etc/UTC", localtime))
+	require.NoError(ioutil.WriteFile(timezone, []byte("America/New_York\n"), 0644))
+
+	newCheck := func(tz string) *systemTimezone {
+		return &systemTimezone{
+			Timezone:  tz,
+			IgnoreTZ:  true,
+			Base:      NewBase("system-timezone", 0),
+			localtime: localtime,
+			timezone:  timezone,
+		}
+	}
+
+	check := newCheck("")
+	check.Run(ctx)
+	assert.False(check.Output().Passed)
+	assert.Error(check.Error())
+
+	for tz, expected := range map[string]bool{"UTC": true, "Etc/UTC": true, "Europe/Dublin": false} {
+		check = newCheck(tz)
+		check.Run(ctx)
+		assert.Equal(expected, check.Output().Passed, tz)
+		assert.Equal(!expected, check.Error() != nil, tz)
+	}
+
+	// without the symlink, fall back to the timezone file
+	require.NoError(os.Remove(localtime))
+	check = newCheck("America/New_York")
+	check.Run(ctx)
+	assert.True(check.Output().Passed, check.Output().Error)
+
+	require.NoError(os.Remove(timezone))
+	check = newCheck("America/New_York")
+	check.Run(ctx)
+	assert.False(check.Output().Passed)
+	assert.Error(check.Error())
+
+	// TZ takes precedence when set.
+	os.Setenv("TZ", ":Asia/Tokyo")
+	defer os.Unsetenv("TZ")
+	check = newCheck("Asia/Tokyo")
+	check.IgnoreTZ = false
+	check.Run(ctx)
+	assert.True(check.Output().Passed, check.Output().Error)
+}
@@ -0,0 +1,178 @@
+package check
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	name := "tls-certificate"
+	registry.AddJobType(name, func() amboy.Job {
+		return &tlsCertificate{
+			Base: NewBase(name, 0), // (name, version)
+		}
+	})
+}
+
+// tlsCertificate loads a PEM encoded certificate (and optionally its
+// chain) and asserts that it is currently valid. Additional
+// assertions about hostnames, the issuing CA, remaining validity,
+// and the matching private key are only made when configured.
+type tlsCertificate struct {
+	Certificate  string   `bson:"certificate" json:"certificate" yaml:"certificate"`
+	KeyFile      string   `bson:"key_file" json:"key_file" yaml:"key_file"`
+	CAFile       string   `bson:"ca_file" json:"ca_file" yaml:"ca_file"`
+	Hostnames    []string `bson:"hostnames" json:"hostnames" yaml:"hostnames"`
+	MinDaysValid int      `bson:"min_days_valid" json:"min_days_valid" yaml:"min_days_valid"`
+	*Base        `bson:"metadata" json:"metadata" yaml:"metadata"`
+}
+
+func (c *tlsCertificate) validate() error {
+	if c.Certificate == "" {
+		return errors.Errorf("no certificate specified for '%s' (%s) check", c.ID(), c.Name())
+	}
+
+	if c.MinDaysValid < 0 {
+		return errors.Errorf("min_days_valid cannot be negative (%d)", c.MinDaysValid)
+	}
+
+	return nil
+}
+
+func (c *tlsCertificate) Run(_ context.Context) {
+	c.startTask()
+	defer c.MarkComplete()
+
+	if err := c.validate(); err != nil {
+		c.setState(false)
+		c.AddError(err)
+		return
+	}
+
+	certPEM, err := ioutil.ReadFile(c.Certificate)
+	if err != nil {
+		c.setState(false)
+		c.AddError(errors.Wrapf(err, "problem reading certificate '%s'", c.Certificate))
+		return
+	}
+
+	chain, err := parseCertificates(certPEM)
+	if err != nil {
+		c.setState(false)
+		c.AddError(errors.Wrapf(err, "problem parsing certificate '%s'", c.Certificate))
+		return
+	}
+	leaf := chain[0]
+
+	catcher := grip.NewCatcher()
+	now := time.Now()
+
+	if now.Before(leaf.NotBefore) {
+		catcher.Add(errors.Errorf("certificate is not valid until %s", leaf.NotBefore))
+	}
+
+	if now.After(leaf.NotAfter) {
+		catcher.Add(errors.Errorf("certificate expired at %s", leaf.NotAfter))
+	} else if c.MinDaysValid > 0 {
+		deadline := now.Add(time.Duration(c.MinDaysValid) * 24 * time.Hour)
+		if deadline.After(leaf.NotAfter) {
+			catcher.Add(errors.Errorf("certificate expires at %s, within %d days",
+				leaf.NotAfter, c.MinDaysValid))
+		}
+	}
+
+	for _, host := range c.Hostnames {
+		catcher.Add(leaf.VerifyHostname(host))
+	}
+
+	if c.CAFile != "" {
+		catcher.Add(verifyCertificateChain(c.CAFile, chain))
+	}
+
+	if c.KeyFile != "" {
+		keyPEM, err := ioutil.ReadFile(c.KeyFile)
+		if err != nil {
+			catcher.Add(errors.Wrapf(err, "problem reading key '%s'", c.KeyFile))
+		} else if _, err = tls.X509KeyPair(certPEM, keyPEM); err != nil {
+			catcher.Add(errors.Wrapf(err, "key '%s' does not match certificate", c.KeyFile))
+		}
+	}
+
+	c.setMessage(fmt.Sprintf("certificate '%s': subject='%s', issuer='%s', "+
+		"not_before='%s', not_after='%s', dns_names=%v",
+		c.Certificate, leaf.Subject, leaf.Issuer, leaf.NotBefore, leaf.NotAfter, leaf.DNSNames))
+
+	if catcher.HasErrors() {
+		c.setState(false)
+		c.AddError(catcher.Resolve())
+		return
+	}
+
+	c.setState(true)
+}
+
+// parseCertificates returns all certificates in a PEM file, in the
+// order they appear. The first certificate is the leaf.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var out []*x509.Certificate
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		out = append(out, cert)
+	}
+
+	if len(out) == 0 {
+		return nil, errors.New("no PEM encoded certificates found")
+	}
+
+	return out, nil
+}
+
+func verifyCertificateChain(caFile string, chain []*x509.Certificate) error {
+	caPEM, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return errors.Wrapf(err, "problem reading CA bundle '%s'", caFile)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return errors.Errorf("no certificates found in CA bundle '%s'", caFile)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err = chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+
+	return errors.Wrapf(err, "problem verifying certificate against CA bundle '%s'", caFile)
+}
@@ -0,0 +1,157 @@
+package check
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type TLSCertificateSuite struct {
+	dir     string
+	caFile  string
+	require *require.Assertions
+	caCert  *x509.Certificate
+	caKey   *ecdsa.PrivateKey
+	check   *tlsCertificate
+	suite.Suite
+}
+
+func TestTLSCertificateSuite(t *testing.T) {
+	suite.Run(t, new(TLSCertificateSuite))
+}
+
+func (s *TLSCertificateSuite) SetupSuite() {
+	var err error
+	s.require = s.Require()
+	s.dir, err = ioutil.TempDir("", "greenbay-tls-")
+	s.require.NoError(err)
+
+	s.caKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.require.NoError(err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "greenbay test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &s.caKey.PublicKey, s.caKey)
+	s.require.NoError(err)
+	s.caCert, err = x509.ParseCertificate(der)
+	s.require.NoError(err)
+
+	s.caFile = filepath.Join(s.dir, "ca.pem")
+	s.writePEM(s.caFile, "CERTIFICATE", der)
+}
+
+func (s *TLSCertificateSuite) TearDownSuite() {
+	s.NoError(os.RemoveAll(s.dir))
+}
+
+func (s *TLSCertificateSuite) SetupTest() {
+	s.check = &tlsCertificate{Base: NewBase("tls-certificate", 0)}
+	s.check.Certificate, s.check.KeyFile = s.issue("leaf", time.Now().Add(30*24*time.Hour))
+}
+
+func (s *TLSCertificateSuite) writePEM(fn, kind string, der []byte) {
+	s.require.NoError(ioutil.WriteFile(fn, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0600))
+}
+
+func (s *TLSCertificateSuite) issue(name string, notAfter time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.require.NoError(err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "db.example.net"},
+		DNSNames:     []string{"db.example.net", "localhost"},
+		NotBefore:    time.Now().Add(-2 * time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, s.caCert, &key.PublicKey, s.caKey)
+	s.require.NoError(err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	s.require.NoError(err)
+
+	certFile := filepath.Join(s.dir, name+".pem")
+	keyFile := filepath.Join(s.dir, name+".key")
+	s.writePEM(certFile, "CERTIFICATE", der)
+	s.writePEM(keyFile, "EC PRIVATE KEY", keyDER)
+
+	return certFile, keyFile
+}
+
+func (s *TLSCertificateSuite) assertResult(expected bool) {
+	s.check.Run(context.Background())
+	output := s.check.Output()
+	s.True(output.Completed)
+	s.Equal(expected, output.Passed, output.Error)
+	if expected {
+		s.NoError(s.check.Error())
+	} else {
+		s.Error(s.check.Error())
+	}
+}
+
+func (s *TLSCertificateSuite) TestMissingCertificateFails() {
+	s.check.Certificate = ""
+	s.Error(s.check.validate())
+	s.assertResult(false)
+}
+
+func (s *TLSCertificateSuite) TestFileWithoutCertificatesFails() {
+	s.check.Certificate = s.check.KeyFile
+	s.assertResult(false)
+}
+
+func (s *TLSCertificateSuite) TestValidCertificatePasses() {
+	s.check.Hostnames = []string{"db.example.net"}
+	s.check.CAFile = s.caFile
+	s.check.MinDaysValid = 7
+	s.assertResult(true)
+}
+
+func (s *TLSCertificateSuite) TestExpiringCertificateFails() {
+	s.check.MinDaysValid = 60
+	s.assertResult(false)
+}
+
+func (s *TLSCertificateSuite) TestExpiredCertificateFails() {
+	s.check.Certificate, s.check.KeyFile = s.issue("expired", time.Now().Add(-time.Hour))
+	s.assertResult(false)
+}
+
+func (s *TLSCertificateSuite) TestHostnameMismatchFails() {
+	s.check.Hostnames = []string{"db.example.net", "other.example.net"}
+	s.assertResult(false)
+}
+
+func (s *TLSCertificateSuite) TestUntrustedCAFails() {
+	s.check.CAFile = s.check.Certificate
+	s.check.Certificate, _ = s.issue("other", time.Now().Add(24*time.Hour))
+	s.assertResult(false)
+}
+
+func (s *TLSCertificateSuite) TestMismatchedKeyFails() {
+	_, s.check.KeyFile = s.issue("other-key", time.Now().Add(24*time.Hour))
+	s.assertResult(false)
+}
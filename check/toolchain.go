@@ -0,0 +1,140 @@
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultToolchainRoot is where the MongoDB toolchains are installed,
+// each in its own "v<N>" directory, except for the original (v0)
+// toolchain, which is installed in the root itself.
+const defaultToolchainRoot = "/opt/mongodbtoolchain"
+
+// legacyToolchainVersions are always registered, even on systems that
+// don't have them, so that configs can refer to them everywhere.
+var legacyToolchainVersions = []int{0, 1, 2}
+
+// The toolchains are discovered when the package loads, so that the
+// checks for each toolchain can be registered. Set
+// GREENBAY_TOOLCHAIN_ROOT to discover toolchains in another directory.
+var (
+	toolchainRoot       = getToolchainRoot()
+	installedToolchains = discoverToolchains(toolchainRoot)
+)
+
+func getToolchainRoot() string {
+	if root := os.Getenv("GREENBAY_TOOLCHAIN_ROOT"); root != "" {
+		return root
+	}
+
+	return defaultToolchainRoot
+}
+
+type toolchain struct {
+	version int
+	bin     string
+}
+
+// toolchainsByVersion sorts toolchains newest first.
+type toolchainsByVersion []toolchain
+
+func (t toolchainsByVersion) Len() int           { return len(t) }
+func (t toolchainsByVersion) Less(i, j int) bool { return t[i].version > t[j].version }
+func (t toolchainsByVersion) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+
+func newToolchain(root string, version int) toolchain {
+	if version == 0 {
+		return toolchain{version: version, bin: filepath.Join(root, "bin")}
+	}
+
+	return toolchain{version: version, bin: filepath.Join(root, fmt.Sprintf("v%d", version), "bin")}
+}
+
+func (t toolchain) name() string { return fmt.Sprintf("v%d", t.version) }
+
+func (t toolchain) path(program string) string { return filepath.Join(t.bin, program) }
+
+func (t toolchain) has(program string) bool {
+	stat, err := os.Stat(t.path(program))
+	return err == nil && !stat.IsDir()
+}
+
+// discoverToolchains returns the toolchains installed in the root
+// directory, newest first.
+func discoverToolchains(root string) []toolchain {
+	var out []toolchain
+
+	if stat, err := os.Stat(filepath.Join(root, "bin")); err == nil && stat.IsDir() {
+		out = append(out, newToolchain(root, 0))
+	}
+
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		return out
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), "v") {
+			continue
+		}
+
+		version, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), "v"))
+		if err != nil || version <= 0 {
+			continue
+		}
+
+		tc := newToolchain(root, version)
+		if stat, err := os.Stat(tc.bin); err == nil && stat.IsDir() {
+			out = append(out, tc)
+		}
+	}
+
+	sort.Sort(toolchainsByVersion(out))
+
+	return out
+}
+
+// registeredToolchains returns the installed toolchains and the
+// legacy toolchains, newest first, which all have checks registered.
+func registeredToolchains(root string, installed []toolchain) []toolchain {
+	out := append([]toolchain{}, installed...)
+
+	for _, version := range legacyToolchainVersions {
+		found := false
+		for _, tc := range installed {
+			if tc.version == version {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			out = append(out, newToolchain(root, version))
+		}
+	}
+
+	sort.Sort(toolchainsByVersion(out))
+
+	return out
+}
+
+// toolchainPrograms returns the path to the program in each installed
+// toolchain that has it, newest first.
+func toolchainPrograms(installed []toolchain, programs ...string) []string {
+	var out []string
+	for _, tc := range installed {
+		for _, program := range programs {
+			if tc.has(program) {
+				out = append(out, tc.path(program))
+				break
+			}
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,105 @@
+package check
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeToolchainFixture(t *testing.T) string {
+	root, err := ioutil.TempDir("", "greenbay-toolchains")
+	require.NoError(t, err)
+
+	for _, fn := range []string{
+		"bin/gcc",
+		"v1/bin/gcc",
+		"v3/bin/gcc",
+		"v3/bin/python",
+		"v4/bin/gcc",
+		"v4/bin/clang",
+		"v4/bin/go",
+		"v4/bin/python3",
+		"v10/bin/gcc",
+		"vnext/bin/gcc",
+		"v5/README",
+	} {
+		path := filepath.Join(root, fn)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte("#!/bin/sh\n"), 0755))
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "v6"), []byte("not a toolchain"), 0644))
+
+	return root
+}
+
+func toolchainVersions(toolchains []toolchain) []int {
+	var out []int
+	for _, tc := range toolchains {
+		out = append(out, tc.version)
+	}
+	return out
+}
+
+func TestDiscoverToolchains(t *testing.T) {
+	assert := assert.New(t)
+	root := writeToolchainFixture(t)
+	defer os.RemoveAll(root)
+
+	found := discoverToolchains(root)
+	assert.Equal([]int{10, 4, 3, 1, 0}, toolchainVersions(found))
+	assert.Equal(filepath.Join(root, "bin"), found[4].bin)
+	assert.Equal(filepath.Join(root, "v10", "bin"), found[0].bin)
+	assert.Equal("v10", found[0].name())
+
+	assert.Equal([]int{10, 4, 3, 2, 1, 0}, toolchainVersions(registeredToolchains(root, found)))
+	assert.Equal([]int{2, 1, 0}, toolchainVersions(registeredToolchains(root, nil)))
+
+	assert.Equal([]string{filepath.Join(root, "v4", "bin", "python3"), filepath.Join(root, "v3", "bin", "python")},
+		toolchainPrograms(found, "python3", "python"))
+	assert.Equal([]string{filepath.Join(root, "v4", "bin", "clang")}, toolchainPrograms(found, "clang"))
+
+	assert.Len(discoverToolchains(filepath.Join(root, "does-not-exist")), 0)
+}
+
+func TestToolchainCheckRegistration(t *testing.T) {
+	assert := assert.New(t)
+	root := writeToolchainFixture(t)
+	defer os.RemoveAll(root)
+
+	originalRoot, originalToolchains := toolchainRoot, installedToolchains
+	defer func() { toolchainRoot, installedToolchains = originalRoot, originalToolchains }()
+	toolchainRoot, installedToolchains = root, discoverToolchains(root)
+
+	compilers := compilerInterfaceFactoryTable()
+	for _, name := range []string{"compile-toolchain-v0", "compile-toolchain-v1", "compile-toolchain-v2",
+		"compile-toolchain-v3", "compile-toolchain-v4", "compile-toolchain-v10", "compile-toolchain-clang-v4"} {
+		assert.Contains(compilers, name)
+	}
+	assert.NotContains(compilers, "compile-toolchain-v5")
+	assert.NotContains(compilers, "compile-toolchain-clang-v3")
+
+	gcc := compilers["compile-toolchain-v10"]().(compileGCC)
+	assert.Equal(filepath.Join(root, "v10", "bin", "gcc"), gcc.bin)
+	assert.Equal(filepath.Join(root, "v10", "bin", "g++"), gcc.cxx)
+
+	gcc = compilers["compile-toolchain-v0"]().(compileGCC)
+	assert.Equal(filepath.Join(root, "bin", "gcc"), gcc.bin)
+
+	// auto prefers the newest toolchain
+	auto := gccCompilerAuto().(*newestCompiler)
+	assert.Equal(filepath.Join(root, "v10", "bin", "gcc"), auto.patterns[0])
+
+	golang := goCompilerIterfaceFactoryTable()
+	assert.Contains(golang, "compile-toolchain-gccgo-v4")
+	assert.Contains(golang, "compile-toolchain-gccgo-v2")
+	assert.NotContains(golang, "compile-toolchain-gccgo-v3")
+
+	scripts := scriptInterpreterTable()
+	assert.Equal(filepath.Join(root, "v4", "bin", "python3"), scripts["run-program-toolchain-python-v4"]().bin)
+	assert.Equal(filepath.Join(root, "v3", "bin", "python"), scripts["run-program-toolchain-python-v3"]().bin)
+	assert.Equal(filepath.Join(root, "v4", "bin", "python3"), pythonCompilerAuto().bin)
+}
@@ -1,23 +1,453 @@
 package check
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/blang/semver"
+	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
 )
 
-func compareVersions(rel string, actual, expected semver.Version) (bool, error) {
-	switch rel {
-	case "gte", "":
-		return actual.GTE(expected), nil
-	case "lte":
-		return actual.LTE(expected), nil
-	case "lt":
-		return actual.LT(expected), nil
-	case "gt":
-		return actual.GT(expected), nil
-	case "eq":
-		return actual.EQ(expected), nil
+// versionRelationships maps the relationship names used by older
+// checks (e.g. python-module-version) to constraint operators.
+var versionRelationships = map[string]string{
+	"":    ">=",
+	"gte": ">=",
+	"lte": "<=",
+	"lt":  "<",
+	"gt":  ">",
+	"eq":  "==",
+}
+
+func versionOpSatisfied(op string, cmp int) bool {
+	switch op {
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
 	default:
-		return false, errors.Errorf("relationship '%s' is not valid", rel)
+		return false
+	}
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Version constraint expressions
+//
+////////////////////////////////////////////////////////////////////////
+
+// versionScheme compares two version strings, returning -1, 0, or 1
+// if the first is less than, equal to, or greater than the second,
+// and an error if either version is not valid in the scheme.
+type versionScheme func(string, string) (int, error)
+
+// versionSchemes holds the supported version orderings, which checks
+// select by name.
+var versionSchemes = map[string]versionScheme{
+	"semver": compareSemver,
+	"loose":  compareLooseVersions,
+	"dpkg":   compareDpkgVersions,
+	"rpm":    compareRPMVersions,
+	"apk":    compareApkVersions,
+	"pep440": comparePEP440Versions,
+	"gem":    compareGemVersions,
+}
+
+// versionMatcher reports whether a version satisfies a comparison
+// with the version in a constraint.
+type versionMatcher func(op, version, spec string) (bool, error)
+
+// versionMatchers holds the schemes whose operators don't only
+// depend on the ordering of versions. Other schemes use
+// orderedVersionMatcher.
+var versionMatchers = map[string]versionMatcher{
+	"pep440": pep440VersionMatcher,
+}
+
+func orderedVersionMatcher(compare versionScheme) versionMatcher {
+	return func(op, version, spec string) (bool, error) {
+		cmp, err := compare(version, spec)
+		if err != nil {
+			return false, err
+		}
+
+		return versionOpSatisfied(op, cmp), nil
+	}
+}
+
+// versionOperators is ordered so that the longest operators match
+// first.
+var versionOperators = []string{"~=", ">=", "<=", "==", "!=", ">", "<", "="}
+
+type versionConstraint struct {
+	op       string
+	version  string
+	wildcard bool
+}
+
+func (c versionConstraint) String() string {
+	if c.wildcard {
+		return c.op + c.version + ".*"
+	}
+
+	return c.op + c.version
+}
+
+// versionConstraints is a parsed constraint expression. Expressions
+// are a set of alternatives separated by "||", each of which is a
+// list of constraints, separated by commas or spaces, that must all
+// be satisfied, e.g. ">=1.2,<2 || ==3.0.*". A bare version is
+// equivalent to "==", "==" and "!=" accept a trailing ".*" to match
+// a version prefix, and "~=" is the PEP 440 compatible release
+// operator.
+type versionConstraints struct {
+	expr         string
+	scheme       string
+	compare      versionScheme
+	match        versionMatcher
+	alternatives [][]versionConstraint
+}
+
+func parseVersionConstraints(scheme, expr string) (*versionConstraints, error) {
+	compare, ok := versionSchemes[scheme]
+	if !ok {
+		return nil, errors.Errorf("version scheme '%s' is not valid", scheme)
+	}
+
+	out := &versionConstraints{
+		expr:    expr,
+		scheme:  scheme,
+		compare: compare,
+		match:   orderedVersionMatcher(compare),
+	}
+
+	if match, ok := versionMatchers[scheme]; ok {
+		out.match = match
+	}
+
+	catcher := grip.NewCatcher()
+	for _, alt := range strings.Split(expr, "||") {
+		var constraints []versionConstraint
+		for _, token := range tokenizeVersionConstraints(alt) {
+			parsed, err := out.parseConstraint(token)
+			if err != nil {
+				catcher.Add(err)
+				continue
+			}
+			constraints = append(constraints, parsed...)
+		}
+
+		if len(constraints) == 0 {
+			catcher.Add(errors.Errorf("version constraint '%s' has an empty alternative", expr))
+			continue
+		}
+
+		out.alternatives = append(out.alternatives, constraints)
+	}
+
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+
+	return out, nil
+}
+
+// tokenizeVersionConstraints splits a list of constraints on commas
+// and spaces, rejoining operators that are separated from their
+// version, as in ">= 1.2".
+func tokenizeVersionConstraints(expr string) []string {
+	var out []string
+	pending := ""
+	for _, field := range strings.FieldsFunc(expr, func(r rune) bool { return r == ' ' || r == ',' }) {
+		field = pending + field
+		pending = ""
+
+		if strings.Trim(field, "<>=!~") == "" {
+			pending = field
+			continue
+		}
+
+		out = append(out, field)
+	}
+
+	if pending != "" {
+		out = append(out, pending)
+	}
+
+	return out
+}
+
+func (vc *versionConstraints) parseConstraint(token string) ([]versionConstraint, error) {
+	c := versionConstraint{}
+	for _, op := range versionOperators {
+		if strings.HasPrefix(token, op) {
+			c.op = op
+			break
+		}
+	}
+	c.version = token[len(c.op):]
+
+	if c.op == "" || c.op == "=" {
+		c.op = "=="
 	}
+
+	if strings.HasSuffix(c.version, ".*") {
+		if c.op != "==" && c.op != "!=" {
+			return nil, errors.Errorf("wildcard versions are only valid with == and != in '%s'", token)
+		}
+
+		c.wildcard = true
+		c.version = strings.TrimSuffix(c.version, ".*")
+	}
+
+	if c.version == "" {
+		return nil, errors.Errorf("version constraint '%s' has no version", token)
+	}
+
+	// wildcard prefixes, like "3.0" in semver, need not be
+	// complete versions.
+	if c.wildcard {
+		if leadingRelease(c.version) == "" {
+			return nil, errors.Errorf("invalid version prefix in constraint '%s'", token)
+		}
+	} else if _, err := vc.compare(c.version, c.version); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s version in constraint '%s'", vc.scheme, token)
+	}
+
+	if c.op != "~=" {
+		return []versionConstraint{c}, nil
+	}
+
+	// "~=2.2.1" is equivalent to ">=2.2.1, ==2.2.*"
+	release := strings.Split(leadingRelease(c.version), ".")
+	if len(release) < 2 || c.wildcard {
+		return nil, errors.Errorf("compatible release constraint '%s' needs at least two components", token)
+	}
+
+	return []versionConstraint{
+		{op: ">=", version: c.version},
+		{op: "==", version: strings.Join(release[:len(release)-1], "."), wildcard: true},
+	}, nil
+}
+
+// leadingRelease returns the dotted numeric prefix of a version,
+// without a leading "v" or an epoch.
+func leadingRelease(version string) string {
+	version = strings.TrimPrefix(version, "v")
+	if idx := strings.IndexAny(version, ":!"); idx >= 0 {
+		version = version[idx+1:]
+	}
+
+	end := 0
+	for end < len(version) && (version[end] == '.' || isDigit(version[end])) {
+		end++
+	}
+
+	return strings.Trim(version[:end], ".")
+}
+
+// matchesVersionPrefix reports if the version begins with the prefix
+// at a component boundary, so that "3.0" matches "3.0.5" and
+// "3.0rc1" but not "3.01". Epochs are ignored unless the prefix
+// includes one.
+func matchesVersionPrefix(version, prefix string) bool {
+	version = strings.TrimPrefix(version, "v")
+	prefix = strings.TrimPrefix(prefix, "v")
+
+	if !strings.ContainsAny(prefix, ":!") {
+		if idx := strings.IndexAny(version, ":!"); idx >= 0 {
+			version = version[idx+1:]
+		}
+	}
+
+	if !strings.HasPrefix(version, prefix) {
+		return false
+	}
+
+	rest := version[len(prefix):]
+	return rest == "" || !isDigit(rest[0])
+}
+
+func (vc *versionConstraints) String() string { return vc.expr }
+
+// check reports if the version satisfies any of the alternatives in
+// the expression, and returns an error if the version is not valid.
+func (vc *versionConstraints) check(version string) (bool, error) {
+	if _, err := vc.compare(version, version); err != nil {
+		return false, errors.Wrapf(err, "invalid %s version '%s'", vc.scheme, version)
+	}
+
+	for _, alt := range vc.alternatives {
+		if vc.satisfiesAll(version, alt) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (vc *versionConstraints) satisfiesAll(version string, constraints []versionConstraint) bool {
+	for _, c := range constraints {
+		if c.wildcard {
+			if matchesVersionPrefix(version, c.version) != (c.op == "==") {
+				return false
+			}
+			continue
+		}
+
+		// both versions were validated when parsing, so
+		// comparisons cannot fail.
+		if ok, _ := vc.match(c.op, version, c.version); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func compareSemver(a, b string) (int, error) {
+	left, err := semver.Parse(a)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	right, err := semver.Parse(b)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	return left.Compare(right), nil
+}
+
+// looseVersion is a tolerant representation of version strings that
+// are not valid semver, such as "2.7", "4.8.5", or "8.0.1-rc1". The
+// numeric components are compared in order, and missing components
+// are treated as zero. Versions with a pre-release suffix sort before
+// the same version without one.
+type looseVersion struct {
+	raw        string
+	components []int
+	prerelease string
+}
+
+func parseLooseVersion(version string) (looseVersion, error) {
+	out := looseVersion{raw: version}
+
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if idx := strings.Index(version, "+"); idx >= 0 {
+		// build metadata does not affect ordering
+		version = version[:idx]
+	}
+
+	if idx := strings.IndexAny(version, "-~"); idx >= 0 {
+		out.prerelease = version[idx+1:]
+		version = version[:idx]
+	}
+
+	for _, part := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return looseVersion{}, errors.Errorf("could not parse version '%s'", out.raw)
+		}
+		out.components = append(out.components, n)
+	}
+
+	return out, nil
+}
+
+func (v looseVersion) String() string { return v.raw }
+
+// compare returns -1, 0, or 1 if v is less than, equal to, or
+// greater than other.
+func (v looseVersion) compare(other looseVersion) int {
+	for i := 0; i < len(v.components) || i < len(other.components); i++ {
+		var l, r int
+		if i < len(v.components) {
+			l = v.components[i]
+		}
+		if i < len(other.components) {
+			r = other.components[i]
+		}
+
+		if l != r {
+			return compareInts(l, r)
+		}
+	}
+
+	switch {
+	case v.prerelease == other.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case other.prerelease == "":
+		return -1
+	default:
+		return compareAlphanumeric(v.prerelease, other.prerelease)
+	}
+}
+
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+// compareAlphanumeric compares strings by splitting them into runs of
+// digits and non-digits, so that "rc10" sorts after "rc9".
+func compareAlphanumeric(a, b string) int {
+	for a != "" && b != "" {
+		var l, r string
+		l, a = splitLeadingRun(a)
+		r, b = splitLeadingRun(b)
+
+		ln, lerr := strconv.Atoi(l)
+		rn, rerr := strconv.Atoi(r)
+		if lerr == nil && rerr == nil {
+			if ln != rn {
+				return compareInts(ln, rn)
+			}
+			continue
+		}
+
+		if l != r {
+			return strings.Compare(l, r)
+		}
+	}
+
+	return compareInts(len(a), len(b))
+}
+
+func splitLeadingRun(s string) (string, string) {
+	idx := 1
+	for idx < len(s) && isDigit(s[idx]) == isDigit(s[0]) {
+		idx++
+	}
+
+	return s[:idx], s[idx:]
+}
+
+func compareLooseVersions(a, b string) (int, error) {
+	left, err := parseLooseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+
+	right, err := parseLooseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	return left.compare(right), nil
 }
@@ -0,0 +1,713 @@
+package check
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+////////////////////////////////////////////////////////////////////////
+//
+// Debian package versions: [epoch:]upstream[-revision]
+//
+////////////////////////////////////////////////////////////////////////
+
+type dpkgVersion struct {
+	epoch    int
+	upstream string
+	revision string
+}
+
+func parseDpkgVersion(version string) (dpkgVersion, error) {
+	out := dpkgVersion{}
+	version = strings.TrimSpace(version)
+
+	if idx := strings.Index(version, ":"); idx >= 0 {
+		epoch, err := strconv.Atoi(version[:idx])
+		if err != nil || epoch < 0 {
+			return out, errors.Errorf("invalid epoch in dpkg version '%s'", version)
+		}
+		out.epoch = epoch
+		version = version[idx+1:]
+	}
+
+	out.upstream = version
+	if idx := strings.LastIndex(version, "-"); idx >= 0 {
+		out.upstream, out.revision = version[:idx], version[idx+1:]
+	}
+
+	if out.upstream == "" || out.upstream[0] < '0' || out.upstream[0] > '9' {
+		return out, errors.Errorf("dpkg version '%s' does not start with a digit", version)
+	}
+
+	for _, r := range out.upstream + out.revision {
+		if !isAlphanumeric(byte(r)) && !strings.ContainsRune(".+~-:", r) {
+			return out, errors.Errorf("invalid character '%c' in dpkg version '%s'", r, version)
+		}
+	}
+
+	return out, nil
+}
+
+func compareDpkgVersions(a, b string) (int, error) {
+	left, err := parseDpkgVersion(a)
+	if err != nil {
+		return 0, err
+	}
+
+	right, err := parseDpkgVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	if left.epoch != right.epoch {
+		return compareInts(left.epoch, right.epoch), nil
+	}
+
+	if cmp := dpkgVerRevCmp(left.upstream, right.upstream); cmp != 0 {
+		return cmp, nil
+	}
+
+	return dpkgVerRevCmp(left.revision, right.revision), nil
+}
+
+// dpkgOrder weights characters as dpkg does: "~" sorts before
+// everything, even the end of the string, and letters sort before
+// other characters.
+func dpkgOrder(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+
+	c := s[i]
+	switch {
+	case isDigit(c):
+		return 0
+	case isLetter(c):
+		return int(c)
+	case c == '~':
+		return -1
+	default:
+		return int(c) + 256
+	}
+}
+
+// dpkgVerRevCmp is a port of verrevcmp from dpkg's lib/dpkg/version.c.
+func dpkgVerRevCmp(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			ac, bc := dpkgOrder(a, i), dpkgOrder(b, j)
+			if ac != bc {
+				return compareInts(ac, bc)
+			}
+			i++
+			j++
+		}
+
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+
+		firstDiff := 0
+		for i < len(a) && isDigit(a[i]) && j < len(b) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = compareInts(int(a[i]), int(b[j]))
+			}
+			i++
+			j++
+		}
+
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+
+	return 0
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// RPM package versions: [epoch:]version[-release]
+//
+////////////////////////////////////////////////////////////////////////
+
+type rpmVersion struct {
+	epoch   int
+	version string
+	release string
+}
+
+func parseRPMVersion(version string) (rpmVersion, error) {
+	out := rpmVersion{}
+	version = strings.TrimSpace(version)
+
+	if idx := strings.Index(version, ":"); idx >= 0 {
+		epoch, err := strconv.Atoi(version[:idx])
+		if err != nil || epoch < 0 {
+			return out, errors.Errorf("invalid epoch in rpm version '%s'", version)
+		}
+		out.epoch = epoch
+		version = version[idx+1:]
+	}
+
+	out.version = version
+	if idx := strings.LastIndex(version, "-"); idx >= 0 {
+		out.version, out.release = version[:idx], version[idx+1:]
+	}
+
+	if strings.IndexFunc(out.version, func(r rune) bool { return r < 128 && isAlphanumeric(byte(r)) }) < 0 {
+		return out, errors.Errorf("rpm version '%s' has no alphanumeric characters", version)
+	}
+
+	return out, nil
+}
+
+// compareRPMVersions compares epoch, version, and release in
+// order. As with rpm's dependency matching, the release is only
+// compared when both versions have one, so "1.2" is equal to
+// "1.2-3.el7".
+func compareRPMVersions(a, b string) (int, error) {
+	left, err := parseRPMVersion(a)
+	if err != nil {
+		return 0, err
+	}
+
+	right, err := parseRPMVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	if left.epoch != right.epoch {
+		return compareInts(left.epoch, right.epoch), nil
+	}
+
+	if cmp := rpmVerCmp(left.version, right.version); cmp != 0 {
+		return cmp, nil
+	}
+
+	if left.release == "" || right.release == "" {
+		return 0, nil
+	}
+
+	return rpmVerCmp(left.release, right.release), nil
+}
+
+// rpmVerCmp is a port of rpmvercmp from rpm's rpmio/rpmvercmp.c,
+// including the handling of "~" (sorts before anything) and "^"
+// (sorts after the base version but before any other addition).
+func rpmVerCmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	isSeparator := func(s string, i int) bool {
+		return i < len(s) && !isAlphanumeric(s[i]) && s[i] != '~' && s[i] != '^'
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for isSeparator(a, i) {
+			i++
+		}
+		for isSeparator(b, j) {
+			j++
+		}
+
+		if (i < len(a) && a[i] == '~') || (j < len(b) && b[j] == '~') {
+			if i >= len(a) || a[i] != '~' {
+				return 1
+			}
+			if j >= len(b) || b[j] != '~' {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		if (i < len(a) && a[i] == '^') || (j < len(b) && b[j] == '^') {
+			if i >= len(a) {
+				return -1
+			}
+			if j >= len(b) {
+				return 1
+			}
+			if a[i] != '^' {
+				return 1
+			}
+			if b[j] != '^' {
+				return -1
+			}
+			i++
+			j++
+			continue
+		}
+
+		if i >= len(a) || j >= len(b) {
+			break
+		}
+
+		match := isLetter
+		numeric := isDigit(a[i])
+		if numeric {
+			match = isDigit
+		}
+
+		si, sj := i, j
+		for i < len(a) && match(a[i]) {
+			i++
+		}
+		for j < len(b) && match(b[j]) {
+			j++
+		}
+
+		left, right := a[si:i], b[sj:j]
+		if right == "" {
+			// segments of different types: numbers are newer
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+
+		if numeric {
+			left = strings.TrimLeft(left, "0")
+			right = strings.TrimLeft(right, "0")
+			if len(left) != len(right) {
+				return compareInts(len(left), len(right))
+			}
+		}
+
+		if cmp := strings.Compare(left, right); cmp != 0 {
+			return cmp
+		}
+	}
+
+	switch {
+	case i >= len(a) && j >= len(b):
+		return 0
+	case i < len(a):
+		return 1
+	default:
+		return -1
+	}
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Alpine package versions: number{.number}[letter]{_suffix[number]}[~hash][-rN]
+//
+////////////////////////////////////////////////////////////////////////
+
+var apkVersionPattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)*)([a-z]?)` +
+	`((?:_(?:alpha|beta|pre|rc|cvs|svn|git|hg|p)[0-9]*)*)(?:~([0-9a-f]+))?(?:-r([0-9]+))?$`)
+var apkSuffixPattern = regexp.MustCompile(`_([a-z]+)([0-9]*)`)
+
+// apkSuffixes are the ranks of the suffixes: versions with the
+// suffixes before apkRelease are pre-releases, and sort before the
+// version without a suffix, and the others sort after it.
+var apkSuffixes = map[string]int{
+	"alpha": 0, "beta": 1, "pre": 2, "rc": 3,
+	"cvs": 5, "svn": 6, "git": 7, "hg": 8, "p": 9,
+}
+
+const apkRelease = 4
+
+type apkVersion struct {
+	numbers  []string
+	letter   string
+	suffixes [][2]int
+	hash     string
+	release  string
+}
+
+func parseApkVersion(version string) (apkVersion, error) {
+	out := apkVersion{}
+	version = strings.TrimSpace(version)
+
+	match := apkVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return out, errors.Errorf("'%s' is not a valid apk version", version)
+	}
+
+	out.numbers = strings.Split(match[1], ".")
+	out.letter = match[2]
+	for _, suffix := range apkSuffixPattern.FindAllStringSubmatch(match[3], -1) {
+		n, _ := strconv.Atoi(suffix[2])
+		out.suffixes = append(out.suffixes, [2]int{apkSuffixes[suffix[1]], n})
+	}
+	out.hash = match[4]
+	out.release = match[5]
+
+	return out, nil
+}
+
+// compareApkNumbers compares the numeric components of versions. As
+// with apk (and Gentoo), components after the first with a leading
+// zero compare as decimal fractions, so "1.01" is less than "1.1".
+func compareApkNumbers(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		l, r := a[i], b[i]
+		if i > 0 && (l[0] == '0' || r[0] == '0') {
+			if cmp := strings.Compare(strings.TrimRight(l, "0"), strings.TrimRight(r, "0")); cmp != 0 {
+				return cmp
+			}
+			continue
+		}
+
+		l, r = strings.TrimLeft(l, "0"), strings.TrimLeft(r, "0")
+		if len(l) != len(r) {
+			return compareInts(len(l), len(r))
+		}
+		if cmp := strings.Compare(l, r); cmp != 0 {
+			return cmp
+		}
+	}
+
+	return compareInts(len(a), len(b))
+}
+
+// compareApkVersions compares versions as apk does, where "_alpha",
+// "_beta", "_pre", and "_rc" mark pre-releases and "_p" (and the
+// version control suffixes) mark versions after the release. As with
+// the rpm scheme, the package release ("-rN") is only compared when
+// both versions have one, so "1.2" is equal to "1.2-r3".
+func compareApkVersions(a, b string) (int, error) {
+	left, err := parseApkVersion(a)
+	if err != nil {
+		return 0, err
+	}
+
+	right, err := parseApkVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	if cmp := compareApkNumbers(left.numbers, right.numbers); cmp != 0 {
+		return cmp, nil
+	}
+
+	if cmp := strings.Compare(left.letter, right.letter); cmp != 0 {
+		return cmp, nil
+	}
+
+	// versions without more suffixes compare as if they had a
+	// suffix between the pre-releases and the others.
+	for i := 0; i < len(left.suffixes) || i < len(right.suffixes); i++ {
+		l, r := [2]int{apkRelease, 0}, [2]int{apkRelease, 0}
+		if i < len(left.suffixes) {
+			l = left.suffixes[i]
+		}
+		if i < len(right.suffixes) {
+			r = right.suffixes[i]
+		}
+
+		if l[0] != r[0] {
+			return compareInts(l[0], r[0]), nil
+		}
+		if l[1] != r[1] {
+			return compareInts(l[1], r[1]), nil
+		}
+	}
+
+	if cmp := strings.Compare(left.hash, right.hash); cmp != 0 {
+		return cmp, nil
+	}
+
+	if left.release == "" || right.release == "" {
+		return 0, nil
+	}
+
+	lr, _ := strconv.Atoi(left.release)
+	rr, _ := strconv.Atoi(right.release)
+
+	return compareInts(lr, rr), nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Python package versions (PEP 440)
+//
+////////////////////////////////////////////////////////////////////////
+
+// pep440Pattern is the permissive version pattern from PEP 440's
+// appendix, which accepts non-normalized forms like "1.0-RC1".
+var pep440Pattern = regexp.MustCompile(`(?i)^v?` +
+	`(?:(?P<epoch>[0-9]+)!)?` +
+	`(?P<release>[0-9]+(?:\.[0-9]+)*)` +
+	`(?P<pre>[-_.]?(?P<pre_l>alpha|a|beta|b|preview|pre|c|rc)[-_.]?(?P<pre_n>[0-9]+)?)?` +
+	`(?P<post>(?:-(?P<post_n1>[0-9]+))|(?:[-_.]?(?P<post_l>post|rev|r)[-_.]?(?P<post_n2>[0-9]+)?))?` +
+	`(?P<dev>[-_.]?(?P<dev_l>dev)[-_.]?(?P<dev_n>[0-9]+)?)?` +
+	`(?:\+(?P<local>[a-z0-9]+(?:[-_.][a-z0-9]+)*))?$`)
+
+type pep440Version struct {
+	epoch   int
+	release []int
+	// pre, post, and dev are sort keys, rather than the literal
+	// segments, so that missing segments sort correctly.
+	pre   [2]int
+	post  int
+	dev   int
+	local []string
+}
+
+var pep440PreReleases = map[string]int{
+	"a": 0, "alpha": 0,
+	"b": 1, "beta": 1,
+	"c": 2, "rc": 2, "pre": 2, "preview": 2,
+}
+
+func parsePEP440Version(version string) (pep440Version, error) {
+	out := pep440Version{}
+
+	match := pep440Pattern.FindStringSubmatch(strings.TrimSpace(version))
+	if match == nil {
+		return out, errors.Errorf("'%s' is not a valid PEP 440 version", version)
+	}
+
+	groups := map[string]string{}
+	for idx, name := range pep440Pattern.SubexpNames() {
+		if name != "" {
+			groups[name] = strings.ToLower(match[idx])
+		}
+	}
+
+	atoi := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+
+	out.epoch = atoi(groups["epoch"])
+	for _, part := range strings.Split(groups["release"], ".") {
+		out.release = append(out.release, atoi(part))
+	}
+
+	hasPre := groups["pre"] != ""
+	hasPost := groups["post"] != ""
+	hasDev := groups["dev"] != ""
+
+	switch {
+	case hasPre:
+		out.pre = [2]int{pep440PreReleases[groups["pre_l"]], atoi(groups["pre_n"])}
+	case hasDev && !hasPost:
+		// "1.0.dev1" sorts before "1.0a1"
+		out.pre = [2]int{-1, 0}
+	default:
+		out.pre = [2]int{math.MaxInt32, 0}
+	}
+
+	out.post = -1
+	if hasPost {
+		out.post = atoi(groups["post_n1"] + groups["post_n2"])
+	}
+
+	out.dev = math.MaxInt32
+	if hasDev {
+		out.dev = atoi(groups["dev_n"])
+	}
+
+	if groups["local"] != "" {
+		out.local = strings.FieldsFunc(groups["local"], func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		})
+	}
+
+	return out, nil
+}
+
+func (v pep440Version) isPreRelease() bool {
+	return v.pre[0] != math.MaxInt32 || v.dev != math.MaxInt32
+}
+
+func (v pep440Version) isPostRelease() bool { return v.post >= 0 }
+
+// compareRelease compares the epoch and release segments of versions,
+// which PEP 440 calls the base version.
+func (v pep440Version) compareRelease(other pep440Version) int {
+	if v.epoch != other.epoch {
+		return compareInts(v.epoch, other.epoch)
+	}
+
+	for i := 0; i < len(v.release) || i < len(other.release); i++ {
+		var l, r int
+		if i < len(v.release) {
+			l = v.release[i]
+		}
+		if i < len(other.release) {
+			r = other.release[i]
+		}
+
+		if l != r {
+			return compareInts(l, r)
+		}
+	}
+
+	return 0
+}
+
+func (v pep440Version) compare(other pep440Version) int {
+	if cmp := v.compareRelease(other); cmp != 0 {
+		return cmp
+	}
+
+	for _, pair := range [][2]int{
+		{v.pre[0], other.pre[0]},
+		{v.pre[1], other.pre[1]},
+		{v.post, other.post},
+		{v.dev, other.dev},
+	} {
+		if pair[0] != pair[1] {
+			return compareInts(pair[0], pair[1])
+		}
+	}
+
+	return comparePEP440Local(v.local, other.local)
+}
+
+// comparePEP440Local orders local version labels: versions without a
+// label sort first, numeric segments sort after alphanumeric ones,
+// and a label that is a prefix of another sorts first.
+func comparePEP440Local(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		ln, lerr := strconv.Atoi(a[i])
+		rn, rerr := strconv.Atoi(b[i])
+
+		switch {
+		case lerr == nil && rerr == nil:
+			if ln != rn {
+				return compareInts(ln, rn)
+			}
+		case lerr == nil:
+			return 1
+		case rerr == nil:
+			return -1
+		default:
+			if cmp := strings.Compare(a[i], b[i]); cmp != 0 {
+				return cmp
+			}
+		}
+	}
+
+	return compareInts(len(a), len(b))
+}
+
+func comparePEP440Versions(a, b string) (int, error) {
+	left, err := parsePEP440Version(a)
+	if err != nil {
+		return 0, err
+	}
+
+	right, err := parsePEP440Version(b)
+	if err != nil {
+		return 0, err
+	}
+
+	return left.compare(right), nil
+}
+
+// pep440VersionMatcher applies operators as PEP 440's version
+// specifiers do: the local label of the version is ignored unless the
+// specifier has one, ">V" doesn't match post-releases of V, and "<V"
+// doesn't match pre-releases of V, unless V is also a post-release
+// or a pre-release.
+func pep440VersionMatcher(op, version, spec string) (bool, error) {
+	candidate, err := parsePEP440Version(version)
+	if err != nil {
+		return false, err
+	}
+
+	specified, err := parsePEP440Version(spec)
+	if err != nil {
+		return false, err
+	}
+
+	if len(specified.local) == 0 {
+		candidate.local = nil
+	}
+
+	cmp := candidate.compare(specified)
+	sameRelease := candidate.compareRelease(specified) == 0
+
+	switch op {
+	case ">":
+		if sameRelease && candidate.isPostRelease() && !specified.isPostRelease() {
+			return false, nil
+		}
+	case "<":
+		if sameRelease && candidate.isPreRelease() && !specified.isPreRelease() {
+			return false, nil
+		}
+	}
+
+	return versionOpSatisfied(op, cmp), nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Ruby gem versions
+//
+////////////////////////////////////////////////////////////////////////
+
+var gemVersionPattern = regexp.MustCompile(`^[0-9]+(?:\.[0-9a-zA-Z]+)*(?:-[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+var gemSegmentPattern = regexp.MustCompile(`[0-9]+|[a-zA-Z]+`)
+
+// compareGemVersions follows Gem::Version: versions are split into
+// numeric and alphabetic segments, missing segments are zero, and
+// alphabetic segments mark pre-releases, so sort before numbers.
+func compareGemVersions(a, b string) (int, error) {
+	var segments [2][]string
+	for idx, v := range []string{a, b} {
+		v = strings.TrimSpace(v)
+		if !gemVersionPattern.MatchString(v) {
+			return 0, errors.Errorf("'%s' is not a valid gem version", v)
+		}
+		segments[idx] = gemSegmentPattern.FindAllString(strings.Replace(v, "-", ".pre.", -1), -1)
+	}
+
+	left, right := segments[0], segments[1]
+	for i := 0; i < len(left) || i < len(right); i++ {
+		l, r := "0", "0"
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+
+		ln, lerr := strconv.Atoi(l)
+		rn, rerr := strconv.Atoi(r)
+		switch {
+		case lerr == nil && rerr == nil:
+			if ln != rn {
+				return compareInts(ln, rn), nil
+			}
+		case lerr == nil:
+			return 1, nil
+		case rerr == nil:
+			return -1, nil
+		default:
+			if cmp := strings.Compare(l, r); cmp != 0 {
+				return cmp, nil
+			}
+		}
+	}
+
+	return 0, nil
+}
+
+func isDigit(c byte) bool        { return c >= '0' && c <= '9' }
+func isLetter(c byte) bool       { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isAlphanumeric(c byte) bool { return isDigit(c) || isLetter(c) }
@@ -3,61 +3,246 @@ package check
 import (
 	"testing"
 
-	"github.com/blang/semver"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestVersionComparison(t *testing.T) {
 	assert := assert.New(t)
 
-	smaller := semver.MustParse("1.0.0")
-	larger := semver.MustParse("2.0.0")
+	_, ok := versionRelationships["foo"]
+	assert.False(ok)
+
+	cases := []struct {
+		rel      string
+		actual   string
+		expected string
+		ok       bool
+	}{
+		{"gte", "1.0.0", "2.0.0", false},
+		{"gte", "1.0.0", "1.0.0", true},
+		{"gte", "2.0.0", "1.0.0", true},
+		{"gt", "1.0.0", "2.0.0", false},
+		{"gt", "2.0.0", "1.0.0", true},
+		{"lt", "1.0.0", "2.0.0", true},
+		{"lt", "2.0.0", "1.0.0", false},
+		{"lte", "2.0.0", "1.0.0", false},
+		{"lte", "1.0.0", "2.0.0", true},
+		{"lte", "2.0.0", "2.0.0", true},
+		{"eq", "1.0.0", "1.0.0", true},
+		{"", "1.0.0", "2.0.0", false},
+	}
+
+	for _, test := range cases {
+		vc, err := parseVersionConstraints("semver", versionRelationships[test.rel]+test.expected)
+		if !assert.NoError(err, test.rel) {
+			continue
+		}
+
+		ok, err := vc.check(test.actual)
+		assert.NoError(err)
+		assert.Equal(test.ok, ok, "%s %s %s", test.actual, test.rel, test.expected)
+	}
+}
 
-	result, err := compareVersions("foo", smaller, larger)
-	assert.Error(err)
-	assert.False(result)
+func TestLooseVersionComparison(t *testing.T) {
+	assert := assert.New(t)
 
-	result, err = compareVersions("gte", smaller, larger)
-	assert.NoError(err)
-	assert.False(result)
+	for _, invalid := range []string{"", "a.b", "1..2", "rc1"} {
+		_, err := parseLooseVersion(invalid)
+		assert.Error(err, invalid)
+	}
+
+	// each pair is in ascending order
+	ordered := [][2]string{
+		{"2.7", "2.7.1"},
+		{"4.8", "4.10"},
+		{"8.0.1-rc1", "8.0.1"},
+		{"8.0.1-rc9", "8.0.1-rc10"},
+		{"1.9.9", "v2"},
+		{"3.6.0-alpha", "3.6.0-beta"},
+	}
+	for _, pair := range ordered {
+		lower, err := parseLooseVersion(pair[0])
+		assert.NoError(err)
+		higher, err := parseLooseVersion(pair[1])
+		assert.NoError(err)
+
+		assert.Equal(-1, lower.compare(higher), "%s < %s", pair[0], pair[1])
+		assert.Equal(1, higher.compare(lower), "%s > %s", pair[1], pair[0])
+	}
+
+	for _, pair := range [][2]string{{"2.7", "2.7.0"}, {"1.0+build1", "1.0"}, {"v3.4", "3.4"}} {
+		a, err := parseLooseVersion(pair[0])
+		assert.NoError(err)
+		b, err := parseLooseVersion(pair[1])
+		assert.NoError(err)
+
+		assert.Equal(0, a.compare(b), "%s == %s", pair[0], pair[1])
+	}
+}
 
-	result, err = compareVersions("gte", smaller, smaller)
-	assert.NoError(err)
-	assert.True(result)
+func TestVersionConstraintParsing(t *testing.T) {
+	assert := assert.New(t)
 
-	result, err = compareVersions("gte", larger, smaller)
-	assert.NoError(err)
-	assert.True(result)
+	_, err := parseVersionConstraints("foo", ">=1")
+	assert.Error(err, "invalid scheme")
 
-	result, err = compareVersions("gt", smaller, larger)
-	assert.NoError(err)
-	assert.False(result)
+	for _, invalid := range []string{"", " , ", ">=foo", "<6 >=x.y", ">=1 ||", ">=1.*", "~=2", "=="} {
+		_, err = parseVersionConstraints("loose", invalid)
+		assert.Error(err, invalid)
+	}
 
-	result, err = compareVersions("gt", larger, smaller)
-	assert.NoError(err)
-	assert.True(result)
+	_, err = parseVersionConstraints("semver", ">=1.2")
+	assert.Error(err, "semver requires complete versions")
 
-	result, err = compareVersions("lt", smaller, larger)
-	assert.NoError(err)
-	assert.True(result)
-
-	result, err = compareVersions("lt", larger, smaller)
-	assert.NoError(err)
-	assert.False(result)
+	for _, valid := range []string{">=1.2,<2 || ==3.0.*", ">= 1.2, < 2", "~=2.2", "!=3.*", "1.0"} {
+		vc, err := parseVersionConstraints("loose", valid)
+		assert.NoError(err, valid)
+		if assert.NotNil(vc) {
+			assert.Equal(valid, vc.String())
+		}
+	}
+}
 
-	result, err = compareVersions("lte", larger, smaller)
-	assert.NoError(err)
-	assert.False(result)
+func TestVersionConstraints(t *testing.T) {
+	assert := assert.New(t)
 
-	result, err = compareVersions("lte", smaller, larger)
+	cases := []struct {
+		scheme  string
+		expr    string
+		version string
+		ok      bool
+	}{
+		{"loose", ">=4.8 <6", "4.8.5", true},
+		{"loose", ">=4.8 <6", "6.0", false},
+		{"loose", ">=4.8 <6", "4.4.7", false},
+		{"loose", ">=4.8,<6", "4.9", true},
+		{"loose", "2.7", "2.7.0", true},
+		{"loose", "=2.7", "2.7.1", false},
+		{"loose", "!=3.0 >2", "3.0", false},
+		{"loose", ">8.0.0", "8.0.1-rc1", true},
+		{"loose", ">=8.0.1", "8.0.1-rc1", false},
+		{"loose", ">=1.2,<2 || ==3.0.*", "1.5", true},
+		{"loose", ">=1.2,<2 || ==3.0.*", "3.0.7", true},
+		{"loose", ">=1.2,<2 || ==3.0.*", "3.01", false},
+		{"loose", ">=1.2,<2 || ==3.0.*", "2.5", false},
+		{"loose", "!=3.0.*", "3.1", true},
+		{"loose", "~=2.2", "2.9", true},
+		{"loose", "~=2.2", "3.0", false},
+		{"loose", "~=2.2.1", "2.2.0", false},
+		{"semver", ">=1.2.0 <2.0.0", "1.10.0", true},
+		{"semver", ">=1.2.0", "1.2.0-rc.1", false},
+		{"dpkg", ">=1:2.0", "1:2.0-1ubuntu1", true},
+		{"dpkg", ">=1:2.0", "3.0", false},
+		{"dpkg", "<2.0", "2.0~rc1-1", true},
+		{"dpkg", "==2.31.*", "1:2.31-0ubuntu9", true},
+		{"rpm", ">=4.8.5", "4.8.5-44.el7", true},
+		{"rpm", ">=4.8.5-45", "4.8.5-44.el7", false},
+		{"rpm", "<1.0", "1.0~rc1", true},
+		{"pep440", ">=2.7", "2.7", true},
+		{"pep440", "<3", "2.7.18", true},
+		{"pep440", ">=1.0", "1.0rc1", false},
+		{"pep440", ">1.0", "1.0.post1", false},
+		{"pep440", ">1.0", "1.0.1.post1", true},
+		{"pep440", ">1.0.post1", "1.0.post2", true},
+		{"pep440", ">1.0", "1.0+local.7", false},
+		{"pep440", "==1.0", "1.0+local.7", true},
+		{"pep440", "!=1.0", "1.0+local.7", false},
+		{"pep440", "==1.0+local.7", "1.0+local.8", false},
+		{"pep440", "<=1.0", "1.0+local.7", true},
+		{"pep440", "<2.0", "2.0rc1", false},
+		{"pep440", "<2.0", "2.0.dev1", false},
+		{"pep440", "<2.0", "1.9", true},
+		{"pep440", "<2.0rc2", "2.0rc1", true},
+		{"pep440", "<=2.0", "2.0rc1", true},
+		{"pep440", "==1.4.*", "1.4.2", true},
+		{"pep440", "==1!1.0", "1.0", false},
+	}
+
+	for _, test := range cases {
+		vc, err := parseVersionConstraints(test.scheme, test.expr)
+		if !assert.NoError(err, test.expr) {
+			continue
+		}
+
+		ok, err := vc.check(test.version)
+		assert.NoError(err, test.version)
+		assert.Equal(test.ok, ok, "%s '%s' satisfies '%s'", test.scheme, test.version, test.expr)
+	}
+
+	vc, err := parseVersionConstraints("semver", ">=1.0.0")
 	assert.NoError(err)
-	assert.True(result)
+	_, err = vc.check("2.7")
+	assert.Error(err)
+}
 
-	result, err = compareVersions("lte", larger, larger)
-	assert.NoError(err)
-	assert.True(result)
+func TestVersionSchemeOrdering(t *testing.T) {
+	assert := assert.New(t)
 
-	result, err = compareVersions("eq", smaller, smaller)
-	assert.NoError(err)
-	assert.True(result)
+	// each list is in strictly ascending order
+	orderings := map[string][]string{
+		"dpkg": {"1.0~~", "1.0~~a", "1.0~", "1.0", "1.0-1", "1.0-1.1", "1.0a", "1.0+b1", "1.2", "1.10", "1:0.1"},
+		"rpm":  {"1.0~rc1", "1.0", "1.0^git1", "1.0a", "1.0b", "1.0.1", "1.1", "1.10", "2", "1:0.1"},
+		"gem":  {"1.0.0.a", "1.0.0-rc1", "1.0.0.pre1", "1.0.0", "1.0.0.1", "1.0.1", "1.10"},
+		"apk": {"1.01", "1.1", "1.2.3_alpha", "1.2.3_alpha2", "1.2.3_beta1", "1.2.3_pre1", "1.2.3_rc1",
+			"1.2.3_rc1_p1", "1.2.3_rc2", "1.2.3-r0", "1.2.3-r1", "1.2.3-r10", "1.2.3_git20240101", "1.2.3_p1",
+			"1.2.3_p2_rc1", "1.2.3_p2", "1.2.3a", "1.2.3.1", "1.2.10"},
+		"pep440": {"1.0.dev1", "1.0a1.dev1", "1.0a1", "1.0a2", "1.0b1", "1.0rc1", "1.0",
+			"1.0+abc", "1.0+5", "1.0.post1.dev1", "1.0.post1", "1.1", "1!0.1"},
+	}
+
+	for scheme, versions := range orderings {
+		compare := versionSchemes[scheme]
+		for i := 0; i < len(versions)-1; i++ {
+			cmp, err := compare(versions[i], versions[i+1])
+			assert.NoError(err)
+			assert.Equal(-1, cmp, "%s: %s < %s", scheme, versions[i], versions[i+1])
+
+			cmp, err = compare(versions[i+1], versions[i])
+			assert.NoError(err)
+			assert.Equal(1, cmp, "%s: %s > %s", scheme, versions[i+1], versions[i])
+		}
+	}
+
+	equivalent := map[string][2]string{
+		"dpkg":   {"0:1.00", "1.0"},
+		"rpm":    {"0:1.0.0", "1.0.0"},
+		"pep440": {"1.0.0-RC1", "1.0rc1"},
+		"gem":    {"1.0", "1.0.0"},
+		"apk":    {"1.2.3", "1.2.3-r2"},
+	}
+	for scheme, pair := range equivalent {
+		cmp, err := versionSchemes[scheme](pair[0], pair[1])
+		assert.NoError(err)
+		assert.Equal(0, cmp, "%s: %s == %s", scheme, pair[0], pair[1])
+	}
+
+	// apk pre-release suffixes sort before the release, even with a
+	// later package release, and "_p" sorts after it.
+	for _, pair := range [][2]string{
+		{"1.2.3_alpha", "1.2.3"},
+		{"1.2.3_beta2", "1.2.3"},
+		{"1.2.3_pre1", "1.2.3"},
+		{"1.2.3_rc1", "1.2.3"},
+		{"1.2.3_rc1-r5", "1.2.3-r0"},
+		{"1.2.3", "1.2.3_p1"},
+		{"1.2.3-r9", "1.2.3_p0-r0"},
+	} {
+		cmp, err := versionSchemes["apk"](pair[0], pair[1])
+		assert.NoError(err)
+		assert.Equal(-1, cmp, "apk: %s < %s", pair[0], pair[1])
+	}
+
+	for scheme, invalid := range map[string]string{
+		"dpkg":   "a1.0",
+		"rpm":    "...",
+		"pep440": "1.0-foo",
+		"semver": "1.0",
+		"loose":  "1.x",
+		"gem":    "1..0",
+		"apk":    "1.2.3_foo",
+	} {
+		_, err := versionSchemes[scheme](invalid, "1.0.0")
+		assert.Error(err, "%s: %s", scheme, invalid)
+	}
 }
@@ -0,0 +1,146 @@
+package check
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+var workspaceSettings = struct {
+	keepOnFailure bool
+	mutex         sync.RWMutex
+}{}
+
+// SetKeepWorkspacesOnFailure controls whether checks that compile or
+// run test programs keep their temporary workspace directories when
+// they fail, for debugging. The path to the workspace is included in
+// the check's message. By default, workspaces are always removed.
+func SetKeepWorkspacesOnFailure(keep bool) {
+	workspaceSettings.mutex.Lock()
+	defer workspaceSettings.mutex.Unlock()
+
+	workspaceSettings.keepOnFailure = keep
+}
+
+func keepWorkspacesOnFailure() bool {
+	workspaceSettings.mutex.RLock()
+	defer workspaceSettings.mutex.RUnlock()
+
+	return workspaceSettings.keepOnFailure
+}
+
+// newTestWorkspace creates a private temporary directory for the files
+// of a single check.
+func newTestWorkspace() (string, error) {
+	dir, err := ioutil.TempDir("", "greenbay-workspace-")
+	if err != nil {
+		return "", errors.Wrap(err, "problem creating workspace")
+	}
+
+	return dir, nil
+}
+
+// cleanupTestWorkspace removes a check's workspace when the check is
+// done, unless the check failed and workspaces are kept on failure, in
+// which case it adds the workspace's path to the check's message.
+func (b *Base) cleanupTestWorkspace(dir string) {
+	if dir == "" {
+		return
+	}
+
+	if !b.getState() && keepWorkspacesOnFailure() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		kept := fmt.Sprintf("kept workspace '%s'", dir)
+		if b.Message == "" {
+			b.Message = kept
+		} else {
+			b.Message = strings.Join([]string{b.Message, kept}, "\n")
+		}
+		return
+	}
+
+	grip.Warning(errors.Wrapf(os.RemoveAll(dir), "problem removing workspace '%s'", dir))
+}
+
+// writeTestWorkspace writes the test body, if any, as "test.<ext>"
+// (or "test" without an extension), and the additional files to the
+// workspace in the options, or to a new temporary directory if there
+// isn't one. It returns the directory; the names of the files to
+// compile (those with one of the source extensions), relative to the
+// directory, with the test body first; and a function that removes
+// the directory, unless it is the check's workspace.
+func writeTestWorkspace(opts compileOptions, testBody, ext string, sourceExtensions ...string) (string, []string, func(), error) {
+	dir := opts.workspace
+	cleanup := func() {}
+	if dir == "" {
+		var err error
+		dir, err = newTestWorkspace()
+		if err != nil {
+			return "", nil, cleanup, err
+		}
+		cleanup = func() { grip.Warning(os.RemoveAll(dir)) }
+	}
+
+	write := func(name, content string) error {
+		if runtime.GOOS == "windows" {
+			content = strings.Replace(content, "\n", "\r\n", -1)
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return errors.Wrapf(err, "problem creating directory for '%s'", name)
+		}
+
+		return errors.Wrapf(ioutil.WriteFile(path, []byte(content), 0644),
+			"problem writing '%s'", name)
+	}
+
+	var sources []string
+	if testBody != "" {
+		sources = append(sources, "test")
+		if ext != "" {
+			sources[0] += "." + ext
+		}
+		if err := write(sources[0], testBody); err != nil {
+			cleanup()
+			return "", nil, func() {}, err
+		}
+	}
+
+	names := make([]string, 0, len(opts.files))
+	for name := range opts.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := write(name, opts.files[name]); err != nil {
+			cleanup()
+			return "", nil, func() {}, err
+		}
+
+		for _, sourceExt := range sourceExtensions {
+			if strings.HasSuffix(name, "."+sourceExt) {
+				sources = append(sources, filepath.FromSlash(name))
+				break
+			}
+		}
+	}
+
+	if len(sources) == 0 {
+		cleanup()
+		return "", nil, func() {}, errors.New("no source files to compile")
+	}
+
+	return dir, sources, cleanup, nil
+}
@@ -0,0 +1,97 @@
+package check
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestWorkspacesAreRemovedOrKept(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test requires gcc and TMPDIR")
+	}
+
+	assert := assert.New(t)
+
+	tmp, err := ioutil.TempDir("", "greenbay-workspaces")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	originalTmp := os.Getenv("TMPDIR")
+	require.NoError(t, os.Setenv("TMPDIR", tmp))
+	defer os.Setenv("TMPDIR", originalTmp)
+	defer SetKeepWorkspacesOnFailure(false)
+
+	workspaces := func() []string {
+		matches, err := filepath.Glob(filepath.Join(tmp, "greenbay-workspace-*"))
+		require.NoError(t, err)
+		return matches
+	}
+
+	run := func(source string) *compileCheck {
+		check := &compileCheck{
+			Base:          NewBase("compile-and-run-gcc-auto", 0),
+			Source:        source,
+			shouldRunCode: true,
+			compiler:      gccCompilerAuto(),
+		}
+		check.Run(context.Background())
+		return check
+	}
+
+	passing := "int main() { return 0; }\n"
+	failing := "int main() { return missing_function(); }\n"
+
+	// workspaces are private to each check, and always removed by default
+	dir, err := newTestWorkspace()
+	require.NoError(t, err)
+	stat, err := os.Stat(dir)
+	require.NoError(t, err)
+	assert.Equal(os.FileMode(0700), stat.Mode().Perm())
+	require.NoError(t, os.RemoveAll(dir))
+
+	check := run(passing)
+	assert.True(check.Output().Passed)
+	assert.Len(workspaces(), 0)
+
+	check = run(failing)
+	assert.False(check.Output().Passed)
+	assert.Len(workspaces(), 0)
+	assert.NotContains(check.Output().Message, "kept workspace")
+
+	// when kept, failed checks leave their workspace and report it
+	SetKeepWorkspacesOnFailure(true)
+
+	check = run(passing)
+	assert.True(check.Output().Passed)
+	assert.Len(workspaces(), 0)
+
+	check = run(failing)
+	assert.False(check.Output().Passed)
+	kept := workspaces()
+	if assert.Len(kept, 1) {
+		assert.Contains(check.Output().Message, kept[0])
+		assert.Contains(check.Output().Message, "missing_function")
+
+		_, err = os.Stat(filepath.Join(kept[0], "test.c"))
+		assert.NoError(err)
+	}
+
+	// programs run by the script checks use workspaces too
+	SetKeepWorkspacesOnFailure(false)
+	program := &scriptCheck{
+		Base:   NewBase("run-sh-script-succeeds", 0),
+		Source: "pwd; exit 1",
+		mode:   scriptSucceeds,
+		script: compileScript{bin: "sh"},
+	}
+	program.Run(context.Background())
+	assert.False(program.Output().Passed)
+	assert.Len(workspaces(), 1)
+}
@@ -0,0 +1,24 @@
+// +build linux
+
+package check
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// readCapabilityXattr returns the raw security.capability extended
+// attribute of a file, and false if the file has no capabilities.
+func readCapabilityXattr(fn string) ([]byte, bool, error) {
+	buf := make([]byte, 64)
+
+	size, err := syscall.Getxattr(fn, "security.capability", buf)
+	if err == syscall.ENODATA || err == syscall.ENOTSUP {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, errors.Wrapf(err, "problem reading capabilities of '%s'", fn)
+	}
+
+	return buf[:size], true, nil
+}
@@ -0,0 +1,59 @@
+// +build linux
+
+package check
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCapabilitiesCheckWithXattrs(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	f, err := ioutil.TempFile("", "greenbay-caps-")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	defer os.Remove(f.Name())
+
+	newCheck := func(required, forbidden []string) *fileCapabilities {
+		return &fileCapabilities{
+			FileName:  f.Name(),
+			Required:  required,
+			Forbidden: forbidden,
+			Base:      NewBase("file-capabilities", 0),
+		}
+	}
+
+	// files without the attribute have no capabilities
+	check := newCheck(nil, []string{"cap_net_bind_service"})
+	check.Run(ctx)
+	assert.True(check.Output().Passed, check.Output().Error)
+
+	check = newCheck([]string{"cap_net_bind_service"}, nil)
+	check.Run(ctx)
+	assert.False(check.Output().Passed)
+	assert.Error(check.Error())
+
+	// setting capabilities requires CAP_SETFCAP and a filesystem
+	// that supports security attributes.
+	err = syscall.Setxattr(f.Name(), "security.capability", encodeFileCapabilities(true, 10), 0)
+	if err != nil {
+		t.Skipf("cannot set file capabilities in this environment: %v", err)
+	}
+
+	check = newCheck([]string{"cap_net_bind_service"}, []string{"cap_sys_admin"})
+	check.Run(ctx)
+	assert.True(check.Output().Passed, check.Output().Error)
+
+	check = newCheck(nil, []string{"net_bind_service"})
+	check.Run(ctx)
+	assert.False(check.Output().Passed)
+	assert.Error(check.Error())
+}
@@ -0,0 +1,14 @@
+// +build !linux
+
+package check
+
+import (
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+func readCapabilityXattr(fn string) ([]byte, bool, error) {
+	return nil, false, errors.Errorf("file capabilities are not supported on this platform (%s)",
+		runtime.GOOS)
+}
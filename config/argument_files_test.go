@@ -0,0 +1,143 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mongodb/greenbay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigReadsArgumentFilesRelativeToConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-config-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "greenbay.yaml")
+	source := filepath.Join(dir, "scripts", "hello.sh")
+	output := filepath.Join(dir, "hello.out")
+	require.NoError(t, os.MkdirAll(filepath.Dir(source), 0755))
+	require.NoError(t, ioutil.WriteFile(source, []byte("echo hello\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(output, []byte("hello\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(fn, []byte(strings.Join([]string{
+		"tests:",
+		"  - name: hello",
+		"    type: run-sh-script",
+		"    suites: [all]",
+		"    args:",
+		"      source_file: scripts/hello.sh",
+		"      output_file: hello.out",
+		"",
+	}, "\n")), 0644))
+
+	conf, err := ReadConfig(fn)
+	require.NoError(t, err)
+	assert.Equal([]string{source, output}, conf.files)
+
+	check, ok := conf.tests["hello"].(greenbay.GoldenOutputChecker)
+	require.True(t, ok)
+	assert.Equal(output, check.ExpectedOutputFile())
+
+	// nothing changed, so the config isn't reloaded, and the tests
+	// are the same.
+	assert.NoError(conf.Reload())
+	assert.True(check == conf.tests["hello"])
+
+	// changes to the files reload the config.
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(output, later, later))
+	assert.NoError(conf.Reload())
+	assert.False(check == conf.tests["hello"])
+	assert.Len(conf.tests, 1)
+
+	// changes to the size of files reload the config, even if their
+	// modification time is the same.
+	check = conf.tests["hello"].(greenbay.GoldenOutputChecker)
+	require.NoError(t, ioutil.WriteFile(output, []byte("hello, world\n"), 0644))
+	require.NoError(t, os.Chtimes(output, later, later))
+	assert.NoError(conf.Reload())
+	assert.False(check == conf.tests["hello"])
+
+	// missing files are errors when loading or reloading the config,
+	// and the tests from before the reload remain.
+	check = conf.tests["hello"].(greenbay.GoldenOutputChecker)
+	stat, err := os.Stat(source)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(source))
+	assert.Equal(fileStamp{}, conf.fileStamps()[source])
+	assert.Error(conf.Reload())
+	assert.Len(conf.tests, 1)
+	assert.True(check == conf.tests["hello"])
+	assert.Equal([]string{source, output}, conf.files)
+	assert.Len(conf.suites["all"], 1)
+
+	// failed reloads are retried.
+	assert.Error(conf.Reload())
+	assert.True(check == conf.tests["hello"])
+
+	_, err = ReadConfig(fn)
+	assert.Error(err)
+
+	// deleting a file is a change, even if it's recreated with the
+	// same modification time and size.
+	conf.stamps = conf.fileStamps()
+	require.NoError(t, ioutil.WriteFile(source, []byte("echo hello\n"), 0644))
+	require.NoError(t, os.Chtimes(source, stat.ModTime(), stat.ModTime()))
+	assert.NoError(conf.Reload())
+	assert.False(check == conf.tests["hello"])
+	assert.Len(conf.tests, 1)
+	assert.NoError(conf.Reload())
+}
+
+func TestUpdateExpectedOutputFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-config-files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "greenbay.yaml")
+	output := filepath.Join(dir, "hello.out")
+	require.NoError(t, ioutil.WriteFile(output, []byte("goodbye\n"), 0600))
+	config := strings.Join([]string{
+		"tests:",
+		"  - name: hello",
+		"    type: run-sh-script",
+		"    args:",
+		"      source: echo hello",
+		"      output_file: hello.out",
+		"  - name: inline",
+		"    type: run-sh-script",
+		"    args:",
+		"      source: echo inline",
+		"      output: goodbye",
+		"",
+	}, "\n")
+	require.NoError(t, ioutil.WriteFile(fn, []byte(config), 0644))
+
+	conf, err := ReadConfig(fn)
+	require.NoError(t, err)
+
+	updates, err := conf.UpdateExpectedOutputs(map[string]string{"hello": "hello\n", "inline": "inline"})
+	assert.NoError(err)
+	assert.Equal([]GoldenOutputUpdate{{Test: "hello", File: output}, {Test: "inline", File: fn}}, updates)
+
+	data, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal("hello\n", string(data))
+
+	stat, err := os.Stat(output)
+	require.NoError(t, err)
+	assert.Equal(os.FileMode(0600), stat.Mode().Perm())
+
+	data, err = ioutil.ReadFile(fn)
+	require.NoError(t, err)
+	assert.Equal(strings.Replace(config, "output: goodbye", `output: "inline"`, 1), string(data))
+}
@@ -2,8 +2,10 @@ package config
 
 import (
 	"encoding/json"
+	"os"
 	"runtime"
 	"sync"
+	"time"
 
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/grip"
@@ -17,6 +19,8 @@ type GreenbayTestConfig struct {
 	RawTests []rawTest            `bson:"tests" json:"tests" yaml:"tests"`
 	tests    map[string]amboy.Job // maping of test names to test objects
 	suites   map[string][]string  // mapping of suite names to test names
+	files    []string             // files that tests read arguments from
+	stamps   map[string]fileStamp // modification times and sizes of the config and files
 	filename string
 	mutex    sync.RWMutex
 }
@@ -38,6 +42,46 @@ func newTestConfig() *GreenbayTestConfig {
 func (c *GreenbayTestConfig) reset() {
 	c.suites = make(map[string][]string)
 	c.tests = make(map[string]amboy.Job)
+	c.files = []string{}
+}
+
+// fileStamp identifies a version of a file. Files can change without
+// changing their modification time, when they change more than once
+// within the resolution of the file system's timestamps, so the size
+// catches some of those changes.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// fileStamps returns the stamps of the config file and the files that
+// tests read arguments from. Files that don't exist have a zero stamp,
+// so that deleting a file is a change.
+func (c *GreenbayTestConfig) fileStamps() map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	for _, fn := range append([]string{c.filename}, c.files...) {
+		stamps[fn] = fileStamp{}
+		if stat, err := os.Stat(fn); err == nil {
+			stamps[fn] = fileStamp{modTime: stat.ModTime(), size: stat.Size()}
+		}
+	}
+
+	return stamps
+}
+
+func sameFileStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for fn, stamp := range a {
+		other, ok := b[fn]
+		if !ok || !other.modTime.Equal(stamp.modTime) || other.size != stamp.size {
+			return false
+		}
+	}
+
+	return true
 }
 
 // ReadConfig takes a path name to a configuration file (yaml
@@ -64,6 +108,7 @@ func ReadConfig(fn string) (*GreenbayTestConfig, error) {
 	if err = c.parseTests(); err != nil {
 		return nil, errors.Wrapf(err, "problem parsing tests from file '%s'", fn)
 	}
+	c.stamps = c.fileStamps()
 
 	grip.Infoln("loading config file:", fn)
 
@@ -72,23 +117,29 @@ func ReadConfig(fn string) (*GreenbayTestConfig, error) {
 
 // Reload reparses the local test file, and makes it possible to use
 // greenbay as a service and change the test definition without
-// restarting the service.
+// restarting the service. The file is only reparsed if it, or one of
+// the files that tests read arguments from, has changed. If the new
+// config has errors, the current tests remain.
 func (c *GreenbayTestConfig) Reload() error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	data, err := getRawConfig(c.filename)
-	if err != nil {
-		return errors.Wrapf(err, "problem reading config data for '%s'", c.filename)
+	if c.stamps != nil && sameFileStamps(c.stamps, c.fileStamps()) {
+		grip.Infoln("config file has not changed:", c.filename)
+		return nil
 	}
 
-	if err = json.Unmarshal(data, c); err != nil {
-		return errors.Wrapf(err, "problem parsing config '%s'", c.filename)
+	conf, err := ReadConfig(c.filename)
+	if err != nil {
+		return errors.Wrapf(err, "problem reloading config '%s'", c.filename)
 	}
 
-	if err = c.parseTests(); err != nil {
-		return errors.Wrapf(err, "problem parsing tests from file '%s'", c.filename)
-	}
+	c.Options = conf.Options
+	c.RawTests = conf.RawTests
+	c.tests = conf.tests
+	c.suites = conf.suites
+	c.files = conf.files
+	c.stamps = conf.stamps
 
 	grip.Infoln("reloaded config file:", c.filename)
 	return nil
@@ -0,0 +1,416 @@
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/greenbay"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+// GoldenOutputUpdate describes an expected output that
+// UpdateExpectedOutputs replaced.
+type GoldenOutputUpdate struct {
+	Test string
+	File string
+}
+
+// UpdateExpectedOutputs replaces the expected output of tests, by name,
+// with their actual output, and returns the outputs that it
+// replaced. Tests that read their expected output from a file
+// (output_file) have the file replaced. Otherwise, the output is
+// replaced in the config file, and the rest of the config file,
+// including comments and formatting, stays the same, which is only
+// possible for YAML config files.
+func (c *GreenbayTestConfig) UpdateExpectedOutputs(outputs map[string]string) ([]GoldenOutputUpdate, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	catcher := grip.NewCatcher()
+	var updates []GoldenOutputUpdate
+	inline := make(map[string]string)
+	for _, name := range names {
+		check, ok := c.tests[name].(greenbay.GoldenOutputChecker)
+		if !ok || check.ExpectedOutputFile() == "" {
+			inline[name] = outputs[name]
+			continue
+		}
+
+		fn := check.ExpectedOutputFile()
+		if err := writeExpectedOutputFile(fn, outputs[name]); err != nil {
+			catcher.Add(errors.Wrapf(err, "problem updating expected output for '%s'", name))
+			continue
+		}
+
+		updates = append(updates, GoldenOutputUpdate{Test: name, File: fn})
+	}
+
+	inlineUpdates, err := c.updateInlineOutputs(inline)
+	catcher.Add(err)
+
+	return append(updates, inlineUpdates...), catcher.Resolve()
+}
+
+func writeExpectedOutputFile(fn, output string) error {
+	var mode os.FileMode = 0644
+	if stat, err := os.Stat(fn); err == nil {
+		mode = stat.Mode()
+	}
+
+	return errors.Wrapf(ioutil.WriteFile(fn, []byte(output), mode),
+		"problem writing expected output file '%s'", fn)
+}
+
+// updateInlineOutputs replaces expected outputs in the config
+// file. This is unsafe, and needs to be used within the lock.
+func (c *GreenbayTestConfig) updateInlineOutputs(outputs map[string]string) ([]GoldenOutputUpdate, error) {
+	if len(outputs) == 0 {
+		return nil, nil
+	}
+
+	format, err := getFormat(c.filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem determining format of file %s", c.filename)
+	}
+
+	if format != amboy.YAML {
+		return nil, errors.Errorf("can only update expected output in yaml config files, not '%s'", c.filename)
+	}
+
+	stat, err := os.Stat(c.filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem finding config file '%s'", c.filename)
+	}
+
+	data, err := ioutil.ReadFile(c.filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "problem reading config file '%s'", c.filename)
+	}
+
+	newline := "\n"
+	if bytes.Contains(data, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+	lines := strings.Split(strings.Replace(string(data), "\r\n", "\n", -1), "\n")
+
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	catcher := grip.NewCatcher()
+	var updates []GoldenOutputUpdate
+	for _, name := range names {
+		updated, err := replaceYAMLOutput(lines, name, outputs[name])
+		if err != nil {
+			catcher.Add(errors.Wrapf(err, "problem updating expected output for '%s'", name))
+			continue
+		}
+
+		lines = updated
+		updates = append(updates, GoldenOutputUpdate{Test: name, File: c.filename})
+	}
+
+	if len(updates) > 0 {
+		err = ioutil.WriteFile(c.filename, []byte(strings.Join(lines, newline)), stat.Mode())
+		if err != nil {
+			return nil, errors.Wrapf(err, "problem writing config file '%s'", c.filename)
+		}
+	}
+
+	return updates, catcher.Resolve()
+}
+
+////////////////////////////////////////////////////////////////////////
+//
+// Line-oriented YAML editing, which only handles block-style test
+// definitions, but keeps everything else in the file intact.
+//
+////////////////////////////////////////////////////////////////////////
+
+func yamlIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// isYAMLBlank reports whether a line is empty or only a comment.
+func isYAMLBlank(line string) bool {
+	line = strings.TrimSpace(line)
+	return line == "" || strings.HasPrefix(line, "#")
+}
+
+func isYAMLItem(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "-")
+}
+
+// yamlKey returns the column of the content of a line, after the
+// indentation and any "- " sequence indicators, and the content.
+func yamlKey(line string) (int, string) {
+	column := yamlIndent(line)
+	for {
+		rest := line[column:]
+		if rest != "-" && !strings.HasPrefix(rest, "- ") {
+			return column, rest
+		}
+
+		column++
+		for column < len(line) && line[column] == ' ' {
+			column++
+		}
+	}
+}
+
+func hasYAMLKey(content, key string) bool {
+	if !strings.HasPrefix(content, key+":") {
+		return false
+	}
+
+	rest := content[len(key)+1:]
+	return rest == "" || rest[0] == ' '
+}
+
+// yamlScalar returns the value of a key on the line, without quotes or
+// a trailing comment.
+func yamlScalar(content, key string) string {
+	value := strings.TrimSpace(content[len(key)+1:])
+
+	switch {
+	case strings.HasPrefix(value, `"`):
+		if end := strings.LastIndex(value, `"`); end > 0 {
+			if unquoted, err := strconv.Unquote(value[:end+1]); err == nil {
+				return unquoted
+			}
+		}
+	case strings.HasPrefix(value, "'"):
+		if end := strings.LastIndex(value, "'"); end > 0 {
+			return strings.Replace(value[1:end], "''", "'", -1)
+		}
+	}
+
+	if idx := strings.Index(value, " #"); idx >= 0 {
+		value = value[:idx]
+	}
+
+	return strings.TrimSpace(value)
+}
+
+// yamlBlockEnd returns the index of the first line, from start, that
+// has content indented at or before the column, or the limit.
+func yamlBlockEnd(lines []string, start, limit, column int) int {
+	for i := start; i < limit; i++ {
+		if !isYAMLBlank(lines[i]) && yamlIndent(lines[i]) <= column {
+			return i
+		}
+	}
+
+	return limit
+}
+
+// findYAMLTest returns the range of lines of a test definition, by
+// name, in the top-level "tests" sequence, and the column of its keys.
+func findYAMLTest(lines []string, test string) (int, int, int, error) {
+	testsStart := -1
+	for i, line := range lines {
+		if yamlIndent(line) == 0 && hasYAMLKey(line, "tests") {
+			testsStart = i + 1
+			break
+		}
+	}
+
+	if testsStart < 0 {
+		return 0, 0, 0, errors.New("config does not have a block of tests")
+	}
+
+	// the sequence ends at the next top-level key.
+	testsEnd := len(lines)
+	for i := testsStart; i < len(lines); i++ {
+		if !isYAMLBlank(lines[i]) && yamlIndent(lines[i]) == 0 && !isYAMLItem(lines[i]) {
+			testsEnd = i
+			break
+		}
+	}
+
+	column := -1
+	for i := testsStart; i < testsEnd; i++ {
+		if isYAMLBlank(lines[i]) {
+			continue
+		}
+
+		if !isYAMLItem(lines[i]) {
+			return 0, 0, 0, errors.New("tests are not a block sequence")
+		}
+
+		column, _ = yamlKey(lines[i])
+		break
+	}
+
+	for i := testsStart; i < testsEnd; i++ {
+		keyColumn, content := yamlKey(lines[i])
+		if keyColumn != column || !hasYAMLKey(content, "name") || yamlScalar(content, "name") != test {
+			continue
+		}
+
+		start := i
+		for j := i; j >= testsStart; j-- {
+			if isYAMLBlank(lines[j]) {
+				continue
+			}
+
+			if itemColumn, _ := yamlKey(lines[j]); isYAMLItem(lines[j]) && itemColumn == column {
+				start = j
+				break
+			}
+		}
+
+		return start, yamlBlockEnd(lines, i+1, testsEnd, column-1), column, nil
+	}
+
+	return 0, 0, 0, errors.Errorf("no test named '%s' in the config", test)
+}
+
+// replaceYAMLOutput replaces the value of the "output" argument of a
+// test, and returns the new lines.
+func replaceYAMLOutput(lines []string, test, output string) ([]string, error) {
+	start, end, column, err := findYAMLTest(lines, test)
+	if err != nil {
+		return nil, err
+	}
+
+	args := -1
+	for i := start; i < end; i++ {
+		if keyColumn, content := yamlKey(lines[i]); keyColumn == column && hasYAMLKey(content, "args") {
+			args = i
+			break
+		}
+	}
+
+	if args < 0 {
+		return nil, errors.Errorf("test '%s' does not have arguments", test)
+	}
+
+	if value := yamlScalar(strings.TrimSpace(lines[args][column:]), "args"); value != "" {
+		return nil, errors.Errorf("arguments for test '%s' are not a block mapping", test)
+	}
+
+	argsEnd := yamlBlockEnd(lines, args+1, end, column)
+
+	outputLine, outputColumn := -1, -1
+	for i := args + 1; i < argsEnd; i++ {
+		if isYAMLBlank(lines[i]) {
+			continue
+		}
+
+		if outputColumn < 0 {
+			outputColumn = yamlIndent(lines[i])
+		}
+
+		if yamlIndent(lines[i]) == outputColumn && hasYAMLKey(strings.TrimSpace(lines[i]), "output") {
+			outputLine = i
+			break
+		}
+	}
+
+	if outputLine < 0 {
+		return nil, errors.Errorf("test '%s' does not have an expected output", test)
+	}
+
+	// the value includes any following lines that are indented more
+	// than the key (i.e. block scalars and multi-line strings), but
+	// not blank lines or comments at the end, unless the value is a
+	// block scalar that keeps its trailing blank lines.
+	header := yamlScalar(strings.TrimSpace(lines[outputLine]), "output")
+	keep := strings.HasPrefix(header, "|") && strings.Contains(header, "+")
+
+	valueEnd := outputLine + 1
+	for i := outputLine + 1; i < argsEnd; i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			if keep {
+				valueEnd = i + 1
+			}
+			continue
+		}
+
+		if yamlIndent(lines[i]) <= outputColumn {
+			break
+		}
+
+		valueEnd = i + 1
+	}
+
+	out := make([]string, 0, len(lines))
+	out = append(out, lines[:outputLine]...)
+	out = append(out, renderYAMLOutput(outputColumn, output)...)
+	out = append(out, lines[valueEnd:]...)
+
+	return out, nil
+}
+
+// renderYAMLOutput renders an output argument as a literal block
+// scalar, or as a double quoted string for output without newlines or
+// that a block scalar can't hold. Block scalars have an indentation
+// indicator when the first line is indented, because YAML would
+// otherwise take the first line's indentation as the block's (or
+// reject a leading tab), and a chomping indicator so that the value
+// has the same trailing newlines as the output.
+func renderYAMLOutput(indent int, output string) []string {
+	key := strings.Repeat(" ", indent) + "output: "
+
+	printable := true
+	for _, r := range output {
+		if r != '\n' && r != '\t' && !unicode.IsPrint(r) {
+			printable = false
+			break
+		}
+	}
+
+	body := strings.TrimRight(output, "\n")
+	if !printable || !strings.Contains(output, "\n") || body == "" {
+		return []string{key + strconv.Quote(output)}
+	}
+
+	header := "|"
+	for _, line := range strings.Split(body, "\n") {
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			header += "2"
+		}
+		break
+	}
+
+	// the default ("clip") keeps a single trailing newline.
+	switch trailing := len(output) - len(body); {
+	case trailing == 0:
+		header += "-"
+	case trailing > 1:
+		header += "+"
+		body = output[:len(output)-1]
+	}
+
+	out := []string{key + header}
+	for _, line := range strings.Split(body, "\n") {
+		if line == "" {
+			out = append(out, "")
+			continue
+		}
+
+		out = append(out, strings.Repeat(" ", indent+2)+line)
+	}
+
+	return out
+}
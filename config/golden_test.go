@@ -0,0 +1,233 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const goldenTestConfig = `# greenbay checks
+options:
+  jobs: 2
+
+tests:
+  - name: hello
+    type: run-bash-script-output
+    suites: [all]
+    args:
+      source: echo hello
+      output: hi # out of date
+  # block outputs are replaced entirely
+  - name: "lines"
+    type: run-bash-script-output
+    args:
+      output: |
+        one
+
+        two
+      source: |
+        echo one
+        echo two
+    suites:
+      - all
+  - type: shell-operation
+    suites: [all]
+    name: after-type
+    args:
+      command: echo after
+      output: 'before'
+
+      working_directory: /tmp
+  - name: no-output
+    type: shell-operation
+    args:
+      command: "true"
+  - name: package-name
+    type: package-installed
+    args:
+      name: only-an-argument
+`
+
+func testYAMLArgs(t *testing.T, data, test string) map[string]interface{} {
+	conf := struct {
+		Tests []struct {
+			Name string                 `json:"name"`
+			Args map[string]interface{} `json:"args"`
+		} `json:"tests"`
+	}{}
+	require.NoError(t, yaml.Unmarshal([]byte(data), &conf))
+
+	for _, raw := range conf.Tests {
+		if raw.Name == test {
+			return raw.Args
+		}
+	}
+
+	require.Fail(t, "no test named "+test)
+	return nil
+}
+
+func TestReplaceYAMLOutput(t *testing.T) {
+	assert := assert.New(t)
+	lines := strings.Split(goldenTestConfig, "\n")
+
+	for test, output := range map[string]string{
+		"hello":      "hello",
+		"lines":      "one\n\nthree\n  indented\n\ttab",
+		"after-type": "after",
+	} {
+		updated, err := replaceYAMLOutput(lines, test, output)
+		if !assert.NoError(err, test) {
+			continue
+		}
+
+		data := strings.Join(updated, "\n")
+		args := testYAMLArgs(t, data, test)
+		assert.Equal(output, args["output"], test)
+
+		// everything else is the same.
+		assert.Contains(data, "# greenbay checks")
+		assert.Contains(data, "  # block outputs are replaced entirely")
+		assert.Equal("/tmp", testYAMLArgs(t, data, "after-type")["working_directory"])
+		assert.Equal("echo one\necho two\n", testYAMLArgs(t, data, "lines")["source"])
+		assert.Equal("echo hello", testYAMLArgs(t, data, "hello")["source"])
+		assert.Equal("only-an-argument", testYAMLArgs(t, data, "package-name")["name"])
+	}
+
+	// block scalars keep leading indentation and trailing newlines,
+	// and replacing them again doesn't leave any lines behind.
+	for _, output := range []string{
+		"  indented\nfirst line",
+		"\n\n  after blank lines\nlast",
+		"one\ntwo\n",
+		"one\ntwo\n\n\n",
+		" both\nends\n\n",
+		"single line\n",
+		"\n\n",
+	} {
+		updated, err := replaceYAMLOutput(lines, "lines", output)
+		require.NoError(t, err, output)
+		assert.Equal(output, testYAMLArgs(t, strings.Join(updated, "\n"), "lines")["output"], output)
+
+		updated, err = replaceYAMLOutput(updated, "lines", "one\n\ntwo\n")
+		require.NoError(t, err, output)
+		assert.Equal(strings.Join(lines, "\n"), strings.Join(updated, "\n"), output)
+	}
+
+	updated, err := replaceYAMLOutput(lines, "hello", "bell\a")
+	assert.NoError(err)
+	assert.Equal("bell\a", testYAMLArgs(t, strings.Join(updated, "\n"), "hello")["output"])
+
+	_, err = replaceYAMLOutput(lines, "no-output", "true")
+	assert.Error(err)
+
+	_, err = replaceYAMLOutput(lines, "does-not-exist", "output")
+	assert.Error(err)
+
+	// names in the arguments of other tests aren't test names.
+	_, err = replaceYAMLOutput(lines, "only-an-argument", "output")
+	assert.Error(err)
+
+	_, err = replaceYAMLOutput([]string{`{"tests": []}`}, "hello", "output")
+	assert.Error(err)
+}
+
+func TestUpdateExpectedOutputs(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-golden")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "greenbay.yaml")
+	require.NoError(t, ioutil.WriteFile(fn, []byte(goldenTestConfig), 0600))
+
+	conf := newTestConfig()
+	conf.filename = fn
+
+	updates, err := conf.UpdateExpectedOutputs(nil)
+	assert.NoError(err)
+	assert.Len(updates, 0)
+
+	updates, err = conf.UpdateExpectedOutputs(map[string]string{
+		"hello":     "hello",
+		"lines":     "one\ntwo",
+		"no-output": "",
+	})
+	assert.Error(err)
+	assert.Equal([]GoldenOutputUpdate{{Test: "hello", File: fn}, {Test: "lines", File: fn}}, updates)
+
+	data, err := ioutil.ReadFile(fn)
+	require.NoError(t, err)
+	assert.Equal("hello", testYAMLArgs(t, string(data), "hello")["output"])
+	assert.Equal("one\ntwo", testYAMLArgs(t, string(data), "lines")["output"])
+
+	stat, err := os.Stat(fn)
+	require.NoError(t, err)
+	assert.Equal(os.FileMode(0600), stat.Mode().Perm())
+
+	// json configs can't be updated without rewriting them
+	jsonFn := filepath.Join(dir, "greenbay.json")
+	jsonData, err := json.Marshal(map[string]interface{}{"tests": []interface{}{}})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(jsonFn, jsonData, 0600))
+	conf.filename = jsonFn
+
+	updates, err = conf.UpdateExpectedOutputs(map[string]string{"hello": "hello"})
+	assert.Error(err)
+	assert.Len(updates, 0)
+}
+
+func TestUpdatedExpectedOutputsRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "greenbay-golden")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "greenbay.yaml")
+	require.NoError(t, ioutil.WriteFile(fn, []byte(strings.Join([]string{
+		"tests:",
+		"  - name: lines",
+		"    type: run-sh-script",
+		"    args:",
+		"      source: echo one",
+		"      output: |",
+		"        one",
+		"      exit_code: 0",
+		"",
+	}, "\n")), 0600))
+
+	for _, output := range []string{
+		"hello",
+		"one\n\ntwo",
+		"  indented\nfirst line",
+		"one\ntwo\n",
+		"one\ntwo\n\n\n",
+		"\tquote \"tab\"\n  # not a comment\n",
+		"bell\a",
+	} {
+		conf, err := ReadConfig(fn)
+		require.NoError(t, err, output)
+
+		_, err = conf.UpdateExpectedOutputs(map[string]string{"lines": output})
+		require.NoError(t, err, output)
+
+		conf, err = ReadConfig(fn)
+		require.NoError(t, err, output)
+
+		data, err := json.Marshal(conf.tests["lines"])
+		require.NoError(t, err, output)
+
+		args := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(data, &args), output)
+		assert.Equal(output, args["output"], output)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/ghodss/yaml"
 	"github.com/mongodb/amboy"
+	"github.com/mongodb/greenbay"
 	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
 )
@@ -73,6 +74,18 @@ func (c *GreenbayTestConfig) parseTests() error {
 			continue
 		}
 
+		// arguments files are relative to the config file, and
+		// are checked here, so that missing files are errors
+		// when loading the config.
+		if check, ok := testJob.(greenbay.ArgumentFilesChecker); ok {
+			files, err := check.ReadArgumentFiles(filepath.Dir(c.filename))
+			if err != nil {
+				catcher.Add(errors.Wrapf(err, "problem reading files for %s", msg.Name))
+				continue
+			}
+			c.files = append(c.files, files...)
+		}
+
 		err = c.addTest(msg.Name, testJob)
 		if err != nil {
 			grip.Alert(err)
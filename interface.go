@@ -51,6 +51,33 @@ type Checker interface {
 	amboy.Job
 }
 
+// GoldenOutputChecker is implemented by checks that compare the output
+// of a program or command to an expected output. ActualOutput returns
+// the output, and true, when the check failed only because the output
+// did not match the expected output, so that the expected output in
+// the configuration can be replaced (e.g. by "greenbay run
+// --update-golden").
+type GoldenOutputChecker interface {
+	ActualOutput() (string, bool)
+
+	// ExpectedOutputFile returns the path of the file that the
+	// check read its expected output from, if any.
+	ExpectedOutputFile() string
+
+	Checker
+}
+
+// ArgumentFilesChecker is implemented by checks that can read some of
+// their arguments, like source code or expected output, from
+// files. ReadArgumentFiles reads the files, resolving relative paths
+// from the directory of the config file, and returns the paths of the
+// files that it read.
+type ArgumentFilesChecker interface {
+	ReadArgumentFiles(string) ([]string, error)
+
+	Checker
+}
+
 // CheckOutput provides a standard report format for tests that
 // includes their result status and other metadata that may be useful
 // in reporting data to users.
@@ -173,6 +173,14 @@ func checks() cli.Command {
 				Usage: fmt.Sprintf("specify the number of parallel tests to run. (Default %d)",
 					defaultNumJobs),
 				Value: defaultNumJobs,
+			},
+			cli.BoolFlag{
+				Name:  "keep-workspaces-on-failure",
+				Usage: "keep the temporary directories of failed compile and script checks, and print their paths",
+			},
+			cli.BoolFlag{
+				Name:  "update-golden",
+				Usage: "replace the expected output of checks whose output doesn't match with the actual output",
 			}),
 		Action: func(c *cli.Context) error {
 			// Note: in the future in may make sense to
@@ -180,6 +188,8 @@ func checks() cli.Command {
 			// underlying processes.
 			ctx := context.Background()
 
+			check.SetKeepWorkspacesOnFailure(c.Bool("keep-workspaces-on-failure"))
+
 			suites := c.StringSlice("suite")
 			tests := c.StringSlice("test")
 			if len(suites) == 0 && len(tests) == 0 {
@@ -199,6 +209,8 @@ func checks() cli.Command {
 				return errors.Wrap(err, "problem prepping to run tests")
 			}
 
+			app.UpdateGolden = c.Bool("update-golden")
+
 			return errors.Wrap(app.Run(ctx), "problem running tests")
 		},
 	}
@@ -16,10 +16,12 @@ package operations
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/queue"
+	"github.com/mongodb/greenbay"
 	"github.com/mongodb/greenbay/config"
 	"github.com/mongodb/greenbay/output"
 	"github.com/mongodb/grip"
@@ -28,13 +30,16 @@ import (
 
 // GreenbayApp encapsulates the execution of a greenbay run. You can
 // construct the object, either with NewApp(), or by building a
-// GreenbayApp structure yourself.
+// GreenbayApp structure yourself. When UpdateGolden is set, the
+// expected output of checks that failed because their output didn't
+// match is replaced with the actual output in the config file.
 type GreenbayApp struct {
-	Output     *output.Options
-	Conf       *config.GreenbayTestConfig
-	NumWorkers int
-	Tests      []string
-	Suites     []string
+	Output       *output.Options
+	Conf         *config.GreenbayTestConfig
+	NumWorkers   int
+	Tests        []string
+	Suites       []string
+	UpdateGolden bool
 }
 
 // NewApp configures the greenbay application and manages the
@@ -104,9 +109,45 @@ func (a *GreenbayApp) Run(ctx context.Context) error {
 	amboy.WaitCtxInterval(ctx, q, 10*time.Millisecond)
 
 	grip.Noticef("checks complete in [num=%d, runtime=%s] ", stats.Total, time.Since(start))
-	if err := a.Output.ProduceResults(ctx, q); err != nil {
-		return errors.Wrap(err, "problems encountered during tests")
+	catcher.Add(errors.Wrap(a.Output.ProduceResults(ctx, q), "problems encountered during tests"))
+
+	if a.UpdateGolden {
+		catcher.Add(errors.Wrap(a.updateGoldenOutputs(), "problem updating expected outputs"))
+	}
+
+	return catcher.Resolve()
+}
+
+// updateGoldenOutputs replaces the expected output of the checks that
+// failed because their output didn't match, and prints a summary of
+// the outputs that it replaced.
+func (a *GreenbayApp) updateGoldenOutputs() error {
+	outputs := make(map[string]string)
+	for check := range a.Conf.GetAllTests(a.Tests, a.Suites) {
+		if check.Err != nil {
+			continue
+		}
+
+		golden, ok := check.Job.(greenbay.GoldenOutputChecker)
+		if !ok {
+			continue
+		}
+
+		if output, ok := golden.ActualOutput(); ok {
+			outputs[golden.ID()] = output
+		}
+	}
+
+	updates, err := a.Conf.UpdateExpectedOutputs(outputs)
+
+	if len(updates) == 0 {
+		fmt.Println("no expected outputs updated")
+	} else {
+		fmt.Printf("updated the expected output of %d check(s):\n", len(updates))
+		for _, update := range updates {
+			fmt.Printf("\t%s (%s)\n", update.Test, update.File)
+		}
 	}
 
-	return nil
+	return err
 }
@@ -2,6 +2,11 @@ package operations
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/mongodb/greenbay/check"
@@ -80,3 +85,60 @@ func (s *AppSuite) TestConstructorFailsWithInvalidOutputConfigurations() {
 // TODO: add tests that exercise successful runs and dispatch actual
 // tests and suites,but to do this we'll want to have better mock
 // tests and configs, so holding off on that until MAKE-101
+
+func (s *AppSuite) TestRunUpdatesGoldenOutputs() {
+	if runtime.GOOS == "windows" {
+		s.T().Skip("test requires sh")
+	}
+
+	dir, err := ioutil.TempDir("", "greenbay-app")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "greenbay.yaml")
+	s.Require().NoError(ioutil.WriteFile(fn, []byte(strings.Join([]string{
+		"tests:",
+		"  - name: echo-hello",
+		"    type: shell-operation",
+		"    suites: [all]",
+		"    # the output changed",
+		"    args:",
+		"      command: echo hello",
+		"      output: goodbye",
+		"  - name: echo-contains",
+		"    type: shell-operation",
+		"    suites: [all]",
+		"    args:",
+		"      command: echo hello",
+		"      output: goodbye",
+		"      match: contains",
+		"  - name: printf-indented",
+		"    type: shell-operation",
+		"    suites: [all]",
+		"    args:",
+		`      command: printf '  one\n  two\n'`,
+		"      output: one two",
+		"",
+	}, "\n")), 0644))
+
+	app, err := NewApp(fn, "", "gotest", true, 2, []string{"all"}, []string{})
+	s.Require().NoError(err)
+	app.UpdateGolden = true
+
+	// the checks fail, but the output is updated.
+	s.Error(app.Run(context.Background()))
+
+	data, err := ioutil.ReadFile(fn)
+	s.Require().NoError(err)
+	s.Contains(string(data), "    # the output changed\n")
+	s.Contains(string(data), "      output: |\n        hello\n  - name: echo-contains")
+	s.Contains(string(data), "      output: goodbye\n      match: contains")
+	s.Contains(string(data), "      output: |2\n          one\n          two\n")
+
+	conf, err := config.ReadConfig(fn)
+	s.Require().NoError(err)
+	app.Conf = conf
+	app.Tests = []string{"echo-hello", "printf-indented"}
+	app.Suites = nil
+	s.NoError(app.Run(context.Background()))
+}